@@ -0,0 +1,232 @@
+package filewriter
+
+import (
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// OverflowPolicy defines what an asynchronous Write does when the
+// queue of records is full.
+type OverflowPolicy int
+
+const (
+	OverflowBlock      OverflowPolicy = iota // wait until the queue has room
+	OverflowDropNewest                       // drop the record being written
+	OverflowDropOldest                       // drop the oldest queued record to make room
+	OverflowSpill                            // write the record to the SpillWriter instead
+)
+
+// asyncWriter moves disk I/O off the caller's path: Write copies
+// the record into a bounded lock-free queue, which is drained by a
+// single goroutine writing the records to the log file.
+type asyncWriter struct {
+	queue *ringQueue
+
+	// the OverflowPolicy and the SpillWriter, copied when the writer
+	// is started, since producers read them without holding fw.mu
+	policy OverflowPolicy
+	spill  io.Writer
+
+	notify  chan struct{} // wakes the writer goroutine up
+	space   chan struct{} // wakes blocked producers up
+	done    chan struct{} // rejects new records and releases blocked producers
+	stopped chan struct{} // stops the writer goroutine once the queue is drained
+	exited  chan struct{}
+
+	// producers holds a read lock while checking closed and pushing a
+	// record, so that stop can wait for the records already on their
+	// way to the queue before the final drain.
+	producers sync.RWMutex
+	closed    atomic.Bool
+	aborted   atomic.Bool // stops the writer goroutine before the queue is empty
+	closeOnce sync.Once
+	dropped   atomic.Uint64
+}
+
+func newAsyncWriter(queueSize int, policy OverflowPolicy, spill io.Writer) *asyncWriter {
+	return &asyncWriter{
+		queue:   newRingQueue(queueSize),
+		policy:  policy,
+		spill:   spill,
+		notify:  make(chan struct{}, 1),
+		space:   make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+		exited:  make(chan struct{}),
+	}
+}
+
+// wake signals ch without blocking; a signal that is already
+// pending is enough to wake the receiver up.
+func wake(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+// enqueue queues a copy of p, applying the OverflowPolicy if the
+// queue is full.
+func (a *asyncWriter) enqueue(fw *FileWriter, p []byte) (int, error) {
+	a.producers.RLock()
+
+	if a.closed.Load() {
+		a.producers.RUnlock()
+		return 0, newError(OpWrite, "", ErrClosed)
+	}
+
+	rec := append([]byte(nil), p...)
+
+	var woken bool
+	for !a.queue.push(rec) {
+		switch a.policy {
+		case OverflowDropNewest:
+			a.producers.RUnlock()
+			a.dropped.Add(1)
+			return len(p), nil
+
+		case OverflowDropOldest:
+			if _, ok := a.queue.pop(); ok {
+				a.dropped.Add(1)
+			}
+
+		case OverflowSpill:
+			a.producers.RUnlock()
+			return a.spill.Write(p)
+
+		default:
+			select {
+			case <-a.space:
+				woken = true
+			case <-a.done:
+				a.producers.RUnlock()
+				return 0, newError(OpWrite, "", ErrClosed)
+			}
+		}
+	}
+
+	a.producers.RUnlock()
+
+	// The drain wakes a single producer up however many records it
+	// popped, so the wakeup is passed on to the next blocked one.
+	if woken {
+		wake(a.space)
+	}
+
+	wake(a.notify)
+
+	return len(p), nil
+}
+
+// run drains the queue until the writer is stopped. Errors are
+// reported through the ErrorHandler.
+func (a *asyncWriter) run(fw *FileWriter) {
+	defer close(a.exited)
+
+	for {
+		a.drain(fw)
+
+		select {
+		case <-a.notify:
+		case <-a.stopped:
+			a.drain(fw)
+			return
+		}
+	}
+}
+
+// drain writes every queued record to the log file, holding fw.mu
+// for the whole batch.
+func (a *asyncWriter) drain(fw *FileWriter) {
+	defer fw.runHooks()
+
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	a.drainLocked(context.Background(), fw)
+}
+
+// drainLocked writes the queued records to the log file until the
+// queue is empty, ctx ends or the writer is aborted. It must be
+// called with fw.mu held.
+func (a *asyncWriter) drainLocked(ctx context.Context, fw *FileWriter) {
+	for !a.aborted.Load() && ctx.Err() == nil {
+		rec, ok := a.queue.pop()
+		if !ok {
+			return
+		}
+
+		wake(a.space)
+
+		_, err := fw.writeEntry(rec, false)
+		if err != nil {
+			fw.ErrorHandler(fw, err)
+		}
+	}
+}
+
+// stop rejects new records and waits until the queued ones are
+// written.
+func (a *asyncWriter) stop() {
+	a.stopContext(context.Background())
+}
+
+// stopContext is like stop, but if ctx ends before the queue is
+// drained, the writer goroutine is aborted and the records left in
+// the queue are dropped. It returns the number of dropped records.
+func (a *asyncWriter) stopContext(ctx context.Context) int {
+	a.closeOnce.Do(func() {
+		a.closed.Store(true)
+		close(a.done)
+
+		// Once the lock is taken, every producer has either queued
+		// its record or seen closed, so the final drain misses none.
+		a.producers.Lock()
+		a.producers.Unlock()
+
+		close(a.stopped)
+	})
+
+	select {
+	case <-a.exited:
+		return 0
+	case <-ctx.Done():
+	}
+
+	a.aborted.Store(true)
+	<-a.exited
+
+	var dropped int
+	for {
+		if _, ok := a.queue.pop(); !ok {
+			break
+		}
+
+		dropped++
+	}
+
+	a.dropped.Add(uint64(dropped))
+
+	return dropped
+}
+
+func (fw *FileWriter) runAsync() {
+	if fw.AsyncQueueSize <= 0 {
+		return
+	}
+
+	fw.async = newAsyncWriter(fw.AsyncQueueSize, fw.OverflowPolicy, fw.SpillWriter)
+	go fw.async.run(fw)
+}
+
+// Dropped returns the number of records dropped by the asynchronous
+// writer because its queue was full.
+func (fw *FileWriter) Dropped() uint64 {
+	if fw.async == nil {
+		return 0
+	}
+
+	return fw.async.dropped.Load()
+}
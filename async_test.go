@@ -0,0 +1,128 @@
+package filewriter
+
+import (
+	"bytes"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAsyncWrite(t *testing.T) {
+	afs := &afero.Afero{Fs: afero.NewMemMapFs()}
+	payload := []byte("Hello, world!\n")
+
+	fw, err := New(
+		"test.log",
+		WithFileSystem(afs),
+		WithLogFlushInterval(0),
+		WithAsyncWrite(8, OverflowBlock),
+	)
+	require.NoError(t, err, "expected no error when creating file writer, got '%v'", err)
+
+	for range 100 {
+		n, err := fw.Write(payload)
+		require.NoError(t, err, "expected no error when writing, got '%v'", err)
+		require.Equal(t, len(payload), n, "expected the whole record to be written")
+	}
+
+	err = fw.Close()
+	require.NoError(t, err, "expected no error when closing, got '%v'", err)
+
+	content, err := afs.ReadFile("test.log")
+	require.NoError(t, err, "expected no error when reading file, got '%v'", err)
+
+	require.Equal(
+		t, bytes.Repeat(payload, 100), content,
+		"expected every record to be written in order",
+	)
+
+	_, err = fw.Write(payload)
+	require.Error(t, err, "expected error when writing to a closed file writer")
+}
+
+func TestAsyncOverflow(t *testing.T) {
+	payload := []byte("Hello, world!\n")
+
+	tests := []struct {
+		name   string
+		policy OverflowPolicy
+	}{
+		{name: "drop newest", policy: OverflowDropNewest},
+		{name: "drop oldest", policy: OverflowDropOldest},
+		{name: "spill", policy: OverflowSpill},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var spill bytes.Buffer
+
+			// The writer goroutine isn't started, so the queue is never
+			// drained and overflows after two records.
+			fw := &FileWriter{async: newAsyncWriter(2, tt.policy, &spill)}
+
+			for range 5 {
+				_, err := fw.Write(payload)
+				require.NoError(t, err, "expected no error when writing, got '%v'", err)
+			}
+
+			if tt.policy == OverflowSpill {
+				require.Equal(t, bytes.Repeat(payload, 3), spill.Bytes(), "expected overflow to be spilled")
+				require.Zero(t, fw.Dropped(), "expected no records to be dropped")
+				return
+			}
+
+			require.Equal(t, uint64(3), fw.Dropped(), "expected overflow to be dropped")
+		})
+	}
+}
+
+func TestAsyncCloseConcurrentWrites(t *testing.T) {
+	afs := &afero.Afero{Fs: afero.NewMemMapFs()}
+	payload := []byte("Hello, world!\n")
+
+	fw, err := New(
+		"test.log",
+		WithFileSystem(afs),
+		WithLogFlushInterval(0),
+		WithAsyncWrite(4, OverflowBlock),
+	)
+	require.NoError(t, err, "expected no error when creating file writer, got '%v'", err)
+
+	var (
+		wg      sync.WaitGroup
+		written atomic.Int64
+	)
+
+	for range 8 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for {
+				_, err := fw.Write(payload)
+				if err != nil {
+					return
+				}
+
+				written.Add(1)
+			}
+		}()
+	}
+
+	err = fw.Close()
+	require.NoError(t, err, "expected no error when closing, got '%v'", err)
+
+	wg.Wait()
+
+	content, err := afs.ReadFile("test.log")
+	require.NoError(t, err, "expected no error when reading file, got '%v'", err)
+
+	require.Equal(
+		t, int(written.Load()), bytes.Count(content, payload),
+		"expected every accepted record to be written",
+	)
+}
@@ -0,0 +1,20 @@
+//go:build darwin || freebsd || netbsd
+
+package filewriter
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// birthTime returns the time the file was created, if the filesystem
+// reports it.
+func birthTime(stat os.FileInfo) (time.Time, bool) {
+	st, ok := stat.Sys().(*syscall.Stat_t)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	return time.Unix(st.Birthtimespec.Unix()), true
+}
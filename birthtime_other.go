@@ -0,0 +1,14 @@
+//go:build !(darwin || freebsd || netbsd || windows)
+
+package filewriter
+
+import (
+	"os"
+	"time"
+)
+
+// birthTime returns the time the file was created, which Linux and
+// the remaining platforms don't report through stat.
+func birthTime(stat os.FileInfo) (time.Time, bool) {
+	return time.Time{}, false
+}
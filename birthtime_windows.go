@@ -0,0 +1,18 @@
+package filewriter
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// birthTime returns the time the file was created, if the filesystem
+// reports it.
+func birthTime(stat os.FileInfo) (time.Time, bool) {
+	attr, ok := stat.Sys().(*syscall.Win32FileAttributeData)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	return time.Unix(0, attr.CreationTime.Nanoseconds()), true
+}
@@ -0,0 +1,97 @@
+package filewriter
+
+import "io"
+
+// Buffer is the in-memory buffer log entries are accumulated in
+// before being written to the log file. It works like bufio.Writer,
+// but its destination can be changed with SetWriter, which is how
+// rotation points it at the new log file, and an error doesn't
+// break it: the data that couldn't be written is kept and the next
+// Flush retries it.
+type Buffer struct {
+	buf []byte
+	wr  io.Writer
+}
+
+// NewBuffer returns a Buffer of the given capacity writing to w. A
+// non-positive size selects the default capacity of 4096 bytes.
+func NewBuffer(w io.Writer, size int) *Buffer {
+	if size <= 0 {
+		size = defaultBufSize
+	}
+
+	return &Buffer{buf: make([]byte, 0, size), wr: w}
+}
+
+// SetWriter changes the destination of the buffer. The buffered data
+// is kept and written to the new destination by the next Flush.
+func (b *Buffer) SetWriter(w io.Writer) {
+	b.wr = w
+}
+
+// Size returns the capacity of the buffer in bytes.
+func (b *Buffer) Size() int {
+	return cap(b.buf)
+}
+
+// Buffered returns the number of bytes held in the buffer.
+func (b *Buffer) Buffered() int {
+	return len(b.buf)
+}
+
+// Available returns the number of bytes that can be buffered before
+// the buffer is full.
+func (b *Buffer) Available() int {
+	return cap(b.buf) - len(b.buf)
+}
+
+// Write appends p to the buffer, flushing it whenever it fills up.
+// If the buffer is empty and p doesn't fit into it, p is written to
+// the destination directly, avoiding the copy.
+func (b *Buffer) Write(p []byte) (int, error) {
+	var written int
+
+	for len(p) > b.Available() {
+		if len(b.buf) == 0 {
+			n, err := b.wr.Write(p)
+			if err == nil && n < len(p) {
+				err = io.ErrShortWrite
+			}
+
+			return written + n, err
+		}
+
+		n := copy(b.buf[len(b.buf):cap(b.buf)], p)
+		b.buf = b.buf[:len(b.buf)+n]
+		written += n
+		p = p[n:]
+
+		err := b.Flush()
+		if err != nil {
+			return written, err
+		}
+	}
+
+	b.buf = append(b.buf, p...)
+
+	return written + len(p), nil
+}
+
+// Flush writes the buffered data to the destination. If only a part
+// of it was written, the rest is kept in the buffer.
+func (b *Buffer) Flush() error {
+	if len(b.buf) == 0 {
+		return nil
+	}
+
+	n, err := b.wr.Write(b.buf)
+	if err == nil && n < len(b.buf) {
+		err = io.ErrShortWrite
+	}
+
+	if n > 0 {
+		b.buf = b.buf[:copy(b.buf, b.buf[n:])]
+	}
+
+	return err
+}
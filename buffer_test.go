@@ -0,0 +1,125 @@
+package filewriter
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBufferSetWriter(t *testing.T) {
+	var oldWriter, newWriter bytes.Buffer
+
+	buf := NewBuffer(&oldWriter, 0)
+	buf.SetWriter(&newWriter)
+
+	payload := []byte("Hello, world!\n")
+
+	_, err := buf.Write(payload)
+	require.NoError(t, err, "expected no error when writing, got '%v'", err)
+
+	err = buf.Flush()
+	require.NoError(t, err, "expected no error when flushing, got '%v'", err)
+
+	require.Empty(t, oldWriter.Bytes(), "expected old writer to be empty")
+	require.Equal(t, payload, newWriter.Bytes(), "unexpected content of new writer")
+}
+
+func TestBufferWrite(t *testing.T) {
+	var w bytes.Buffer
+	buf := NewBuffer(&w, 8)
+
+	_, err := buf.Write([]byte("12345"))
+	require.NoError(t, err, "expected no error when writing, got '%v'", err)
+	require.Equal(t, 5, buf.Buffered(), "expected data to be buffered")
+
+	_, err = buf.Write([]byte("6789"))
+	require.NoError(t, err, "expected no error when writing, got '%v'", err)
+	require.Equal(t, "12345678", w.String(), "expected full buffer to be flushed")
+	require.Equal(t, 1, buf.Buffered(), "expected rest of data to be buffered")
+
+	err = buf.Flush()
+	require.NoError(t, err, "expected no error when flushing, got '%v'", err)
+
+	// Data larger than the buffer bypasses an empty buffer.
+	_, err = buf.Write([]byte("0123456789"))
+	require.NoError(t, err, "expected no error when writing, got '%v'", err)
+	require.Equal(t, "1234567890123456789", w.String(), "expected large data to be written directly")
+	require.Zero(t, buf.Buffered(), "expected buffer to be empty")
+}
+
+// failingWriter writes at most n bytes and fails afterwards.
+type failingWriter struct {
+	bytes.Buffer
+	n int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if len(p) > w.n {
+		n, _ := w.Buffer.Write(p[:w.n])
+		w.n = 0
+		return n, errors.New("no space left")
+	}
+
+	w.n -= len(p)
+	return w.Buffer.Write(p)
+}
+
+func TestBufferFlushError(t *testing.T) {
+	w := &failingWriter{n: 3}
+	buf := NewBuffer(w, 0)
+
+	_, err := buf.Write([]byte("Hello, world!\n"))
+	require.NoError(t, err, "expected no error when writing, got '%v'", err)
+
+	err = buf.Flush()
+	require.Error(t, err, "expected flush to fail")
+	require.Equal(t, len("Hello, world!\n")-3, buf.Buffered(), "expected unwritten data to be kept")
+
+	w.n = 100
+
+	err = buf.Flush()
+	require.NoError(t, err, "expected retried flush to succeed, got '%v'", err)
+	require.Equal(t, "Hello, world!\n", w.String(), "unexpected written data")
+}
+
+var benchRecord = []byte(`{"level":"info","time":"2024-01-02T03:04:05Z","message":"Hello, world!"}` + "\n")
+
+func BenchmarkBuffer(b *testing.B) {
+	buf := NewBuffer(io.Discard, 0)
+
+	b.SetBytes(int64(len(benchRecord)))
+	b.ReportAllocs()
+
+	for b.Loop() {
+		buf.Write(benchRecord)
+	}
+}
+
+func BenchmarkBufioWriter(b *testing.B) {
+	buf := bufio.NewWriter(io.Discard)
+
+	b.SetBytes(int64(len(benchRecord)))
+	b.ReportAllocs()
+
+	for b.Loop() {
+		buf.Write(benchRecord)
+	}
+}
+
+func BenchmarkFileWriterWrite(b *testing.B) {
+	fw, err := New("test.log", WithFileSystem(afero.NewMemMapFs()), WithLogFlushInterval(0))
+	require.NoError(b, err, "expected no error when creating file writer, got '%v'", err)
+	defer fw.Close()
+
+	b.SetBytes(int64(len(benchRecord)))
+	b.ReportAllocs()
+
+	for b.Loop() {
+		fw.Write(benchRecord)
+	}
+}
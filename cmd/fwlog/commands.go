@@ -0,0 +1,297 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	filewriter "github.com/mtchuikov/file-writer"
+	"github.com/mtchuikov/file-writer/reader"
+)
+
+func runCat(args []string, stdout, stderr io.Writer) error {
+	fs, lf := newFlagSet("cat", stderr)
+
+	name, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+
+	opts, err := lf.options()
+	if err != nil {
+		return err
+	}
+
+	r, err := reader.Open(name, reader.WithOptions(opts...))
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	_, err = io.Copy(stdout, r)
+	return err
+}
+
+func runTail(args []string, stdout, stderr io.Writer) error {
+	fs, lf := newFlagSet("tail", stderr)
+	n := fs.Int("n", 10, "the number of last lines to print")
+	follow := fs.Bool("f", false, "follow the log, surviving rotation")
+	poll := fs.Duration("poll", 250*time.Millisecond, "how often the log is checked when following")
+
+	name, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if *n < 0 {
+		fmt.Fprintf(stderr, "fwlog: -n must not be negative, got %d\n\n", *n)
+		fs.Usage()
+		return errUsage
+	}
+
+	opts, err := lf.options()
+	if err != nil {
+		return err
+	}
+
+	offset, err := printLastLines(stdout, filewriter.LiveSegment(name, opts...), *n)
+	if err != nil || !*follow {
+		return err
+	}
+
+	f, err := reader.Follow(name,
+		reader.WithOptions(opts...),
+		reader.WithOffset(offset),
+		reader.WithPollInterval(*poll),
+	)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(stdout, f)
+	return err
+}
+
+// printLastLines prints the last n lines of the segment and returns
+// the number of bytes read from it.
+func printLastLines(w io.Writer, s filewriter.Segment, n int) (int64, error) {
+	rc, err := s.Open()
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+
+	if err != nil {
+		return 0, err
+	}
+	defer rc.Close()
+
+	var (
+		last   = make([]string, 0, n)
+		offset int64
+		br     = bufio.NewReader(rc)
+	)
+
+	for {
+		line, err := br.ReadString('\n')
+		offset += int64(len(line))
+
+		if line != "" && n > 0 {
+			if len(last) == n {
+				last = append(last[:0], last[1:]...)
+			}
+
+			last = append(last, line)
+		}
+
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	_, err = io.WriteString(w, strings.Join(last, ""))
+	return offset, err
+}
+
+func runRotate(args []string, stdout, stderr io.Writer) error {
+	fs, lf := newFlagSet("rotate", stderr)
+	pid := fs.Int("pid", 0, "the process to signal to rotate its log, instead of rotating offline")
+	pidFile := fs.String("pidfile", "", "the file to read the pid of the process to signal from")
+	compress := fs.Bool("compress", true, "compress the backup when rotating offline")
+
+	err := fs.Parse(args)
+	if err != nil {
+		return errUsage
+	}
+
+	if *pidFile != "" {
+		data, err := os.ReadFile(*pidFile)
+		if err != nil {
+			return err
+		}
+
+		*pid, err = strconv.Atoi(strings.TrimSpace(string(data)))
+		if err != nil {
+			return fmt.Errorf("invalid pid file %q: %w", *pidFile, err)
+		}
+	}
+
+	if *pid > 0 {
+		return signalRotate(*pid)
+	}
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errUsage
+	}
+
+	opts, err := lf.options()
+	if err != nil {
+		return err
+	}
+
+	opts = append(opts,
+		filewriter.WithFileCompress(*compress),
+		filewriter.WithLogFlushInterval(0),
+	)
+
+	fw, err := filewriter.New(fs.Arg(0), opts...)
+	if err != nil {
+		return err
+	}
+
+	return errors.Join(fw.Rotate(), fw.Close())
+}
+
+func runPrune(args []string, stdout, stderr io.Writer) error {
+	fs, lf := newFlagSet("prune", stderr)
+	maxBackups := fs.Int("max-backups", 0, "the maximum number of backups to keep")
+	maxAge := fs.Duration("max-age", 0, "the maximum age of a backup")
+	maxTotalSize := fs.Float64("max-total-size", 0, "the maximum total size of backups (in MB)")
+
+	name, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if *maxBackups <= 0 && *maxAge <= 0 && *maxTotalSize <= 0 {
+		return errors.New("no retention limit set")
+	}
+
+	opts, err := lf.options()
+	if err != nil {
+		return err
+	}
+
+	opts = append(opts,
+		filewriter.WithFileMaxBackups(*maxBackups),
+		filewriter.WithFileMaxAge(*maxAge),
+		filewriter.WithFileMaxTotalSize(*maxTotalSize),
+	)
+
+	removed, err := filewriter.Prune(name, opts...)
+	for _, path := range removed {
+		fmt.Fprintln(stdout, "removed", path)
+	}
+
+	return err
+}
+
+var levels = map[string]filewriter.CompressionLevel{
+	"default": filewriter.LevelDefault,
+	"fastest": filewriter.LevelFastest,
+	"better":  filewriter.LevelBetter,
+	"best":    filewriter.LevelBest,
+}
+
+func runCompress(args []string, stdout, stderr io.Writer) error {
+	fs, lf := newFlagSet("compress", stderr)
+	level := fs.String("level", "default", "the compression level: default, fastest, better or best")
+
+	name, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+
+	l, ok := levels[*level]
+	if !ok {
+		return fmt.Errorf("unknown compression level %q", *level)
+	}
+
+	opts, err := lf.options()
+	if err != nil {
+		return err
+	}
+
+	events, err := filewriter.CompressBackups(name, append(opts, filewriter.WithFileCompressLevel(l))...)
+	for _, e := range events {
+		if e.Err == nil {
+			fmt.Fprintln(stdout, "compressed", e.Source, "->", e.Backup)
+		}
+	}
+
+	return err
+}
+
+func runVerify(args []string, stdout, stderr io.Writer) error {
+	fs, lf := newFlagSet("verify", stderr)
+
+	name, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+
+	opts, err := lf.options()
+	if err != nil {
+		return err
+	}
+
+	segments, err := filewriter.Segments(name, opts...)
+	if err != nil {
+		return err
+	}
+
+	var failed int
+	for _, s := range segments {
+		if s.Live {
+			continue
+		}
+
+		err = verifySegment(s)
+		if err != nil {
+			failed++
+			fmt.Fprintf(stdout, "FAIL %s: %v\n", s.Path, err)
+			continue
+		}
+
+		fmt.Fprintln(stdout, "OK", s.Path)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of the backups are corrupted", failed)
+	}
+
+	return nil
+}
+
+// verifySegment reads the segment to its end, which makes the codec
+// check its integrity, e.g. the CRC-32 of a gzip stream.
+func verifySegment(s filewriter.Segment) error {
+	rc, err := s.Open()
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(io.Discard, rc)
+
+	return errors.Join(err, rc.Close())
+}
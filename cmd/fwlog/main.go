@@ -0,0 +1,127 @@
+// Command fwlog manages the log files written by a FileWriter: it
+// reads them as a single stream, follows them, rotates, prunes and
+// compresses them, and verifies the integrity of the backups.
+//
+// Usage:
+//
+//	fwlog <command> [flags] <log file>
+//
+// The log file is the path the FileWriter was created with, not the
+// path of a backup.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	filewriter "github.com/mtchuikov/file-writer"
+)
+
+const usage = `usage: fwlog <command> [flags] <log file>
+
+commands:
+  cat       decompress and print every segment of the log in order
+  tail      print the last lines of the log, -f follows it
+  rotate    signal a running process to rotate, or rotate offline
+  prune     remove the backups exceeding the retention limits
+  compress  compress the uncompressed backups
+  verify    check the integrity of every backup
+
+run 'fwlog <command> -h' for the flags of a command
+`
+
+// errUsage is returned when the command line is invalid; the usage
+// has already been printed by then.
+var errUsage = errors.New("invalid usage")
+
+type command func(args []string, stdout, stderr io.Writer) error
+
+var commands = map[string]command{
+	"cat":      runCat,
+	"tail":     runTail,
+	"rotate":   runRotate,
+	"prune":    runPrune,
+	"compress": runCompress,
+	"verify":   runVerify,
+}
+
+func main() {
+	err := run(os.Args[1:], os.Stdout, os.Stderr)
+	if errors.Is(err, errUsage) {
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "fwlog:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdout, stderr io.Writer) error {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return errUsage
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "fwlog: unknown command %q\n\n%s", args[0], usage)
+		return errUsage
+	}
+
+	return cmd(args[1:], stdout, stderr)
+}
+
+// logFlags holds the flags describing how the log was written,
+// which every command needs to find the backups.
+type logFlags struct {
+	postfix string
+	codec   string
+}
+
+func newFlagSet(name string, stderr io.Writer) (*flag.FlagSet, *logFlags) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "usage: fwlog %s [flags] <log file>\n\nflags:\n", name)
+		fs.PrintDefaults()
+	}
+
+	lf := &logFlags{}
+	fs.StringVar(&lf.postfix, "postfix", time.RFC3339, "the time layout of the backup postfix")
+	fs.StringVar(&lf.codec, "codec", filewriter.Gzip.Name(), "the codec backups are compressed with")
+
+	return fs, lf
+}
+
+// parse parses the flags and returns the log file name.
+func parse(fs *flag.FlagSet, args []string) (string, error) {
+	err := fs.Parse(args)
+	if err != nil {
+		return "", errUsage
+	}
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return "", errUsage
+	}
+
+	return fs.Arg(0), nil
+}
+
+// options returns the FileWriter options matching the flags.
+func (lf *logFlags) options() ([]filewriter.Option, error) {
+	codec, ok := filewriter.CodecByName(lf.codec)
+	if !ok {
+		return nil, fmt.Errorf("unknown codec %q", lf.codec)
+	}
+
+	return []filewriter.Option{
+		filewriter.WithFileRotatePostfix(lf.postfix),
+		filewriter.WithFileCodec(codec),
+	}, nil
+}
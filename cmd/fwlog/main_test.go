@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	filewriter "github.com/mtchuikov/file-writer"
+	"github.com/stretchr/testify/require"
+)
+
+// writeLog writes one line per segment, rotating between them.
+func writeLog(t *testing.T, name string, opts ...filewriter.Option) {
+	opts = append(opts, filewriter.WithLogFlushInterval(0))
+
+	fw, err := filewriter.New(name, opts...)
+	require.NoError(t, err, "expected no error when creating file writer, got '%v'", err)
+
+	for i, line := range []string{"one\n", "two\n", "three\n"} {
+		if i > 0 {
+			err = fw.Rotate()
+			require.NoError(t, err, "expected no error when rotating, got '%v'", err)
+		}
+
+		_, err = fw.Write([]byte(line))
+		require.NoError(t, err, "expected no error when writing, got '%v'", err)
+	}
+
+	err = fw.Close()
+	require.NoError(t, err, "expected no error when closing, got '%v'", err)
+}
+
+func runOutput(t *testing.T, args ...string) string {
+	var stdout, stderr bytes.Buffer
+
+	err := run(args, &stdout, &stderr)
+	require.NoError(t, err, "expected no error when running %v, got '%v': %s", args, err, stderr.String())
+
+	return stdout.String()
+}
+
+func TestCat(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "test.log")
+	writeLog(t, name)
+
+	out := runOutput(t, "cat", name)
+	require.Equal(t, "one\ntwo\nthree\n", out, "unexpected log content")
+}
+
+func TestTail(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "test.log")
+
+	err := os.WriteFile(name, []byte("one\ntwo\nthree\n"), 0o644)
+	require.NoError(t, err, "expected no error when writing log file, got '%v'", err)
+
+	out := runOutput(t, "tail", "-n", "2", name)
+	require.Equal(t, "two\nthree\n", out, "unexpected tail")
+}
+
+func TestCompressAndVerify(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "test.log")
+	writeLog(t, name, filewriter.WithFileCompress(false))
+
+	out := runOutput(t, "compress", name)
+	require.Contains(t, out, "compressed", "expected backups to be compressed")
+
+	segments, err := filewriter.Segments(name)
+	require.NoError(t, err, "expected no error when listing segments, got '%v'", err)
+	require.Len(t, segments, 3, "unexpected number of segments")
+	require.NotNil(t, segments[0].Codec, "expected backup to be compressed")
+
+	runOutput(t, "verify", name)
+
+	err = os.WriteFile(segments[0].Path, []byte("corrupted"), 0o644)
+	require.NoError(t, err, "expected no error when corrupting backup, got '%v'", err)
+
+	var stdout, stderr bytes.Buffer
+	err = run([]string{"verify", name}, &stdout, &stderr)
+	require.Error(t, err, "expected corrupted backup to fail verification")
+	require.Contains(t, stdout.String(), "FAIL "+segments[0].Path, "expected corrupted backup to be reported")
+}
+
+func TestRotateAndPrune(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "test.log")
+	writeLog(t, name)
+
+	runOutput(t, "rotate", name)
+
+	segments, err := filewriter.Segments(name)
+	require.NoError(t, err, "expected no error when listing segments, got '%v'", err)
+	require.Len(t, segments, 4, "unexpected number of segments after rotation")
+
+	out := runOutput(t, "prune", "-max-backups", "1", name)
+	require.Contains(t, out, "removed", "expected backups to be removed")
+
+	segments, err = filewriter.Segments(name)
+	require.NoError(t, err, "expected no error when listing segments, got '%v'", err)
+	require.Len(t, segments, 2, "unexpected number of segments after pruning")
+}
+
+func TestUnknownCommand(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	err := run([]string{"unknown"}, &stdout, &stderr)
+	require.ErrorIs(t, err, errUsage, "expected usage error")
+}
+
+func TestTailNegativeLines(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	err := run([]string{"tail", "-n", "-1", "test.log"}, &stdout, &stderr)
+	require.ErrorIs(t, err, errUsage, "expected usage error")
+	require.Contains(t, stderr.String(), "-n must not be negative", "expected the invalid flag to be named")
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package main
+
+import "errors"
+
+// signalRotate fails on platforms without SIGUSR1.
+func signalRotate(pid int) error {
+	return errors.New("signaling a process is not supported on this platform")
+}
@@ -0,0 +1,11 @@
+//go:build unix
+
+package main
+
+import "syscall"
+
+// signalRotate asks the process to rotate its log file by sending
+// it SIGUSR1, which FileWriter.HandleDefaultSignals handles.
+func signalRotate(pid int) error {
+	return syscall.Kill(pid, syscall.SIGUSR1)
+}
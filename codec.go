@@ -0,0 +1,188 @@
+package filewriter
+
+import (
+	"io"
+	"strings"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/s2"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+)
+
+// CompressionLevel is a codec-independent compression level. Each
+// codec maps it onto the closest level it supports.
+type CompressionLevel int
+
+const (
+	LevelDefault CompressionLevel = iota // the codec's own default
+	LevelFastest                         // the fastest level, the lowest ratio
+	LevelBetter                          // a better ratio at a moderate CPU cost
+	LevelBest                            // the best ratio, the slowest level
+)
+
+// Codec compresses rotated log files. The extension returned by
+// Ext, including the leading dot, is appended to the backup name,
+// which allows the codec of a backup to be recognized later.
+type Codec interface {
+	Name() string
+	Ext() string
+	NewWriter(w io.Writer, level CompressionLevel) (io.WriteCloser, error)
+	NewReader(r io.Reader) (io.ReadCloser, error)
+}
+
+var (
+	Gzip   Codec = gzipCodec{}
+	Zstd   Codec = zstdCodec{}
+	S2     Codec = s2Codec{}
+	Snappy Codec = snappyCodec{}
+	LZ4    Codec = lz4Codec{}
+)
+
+// codecs lists every codec known to the package.
+var codecs = []Codec{Gzip, Zstd, S2, Snappy, LZ4}
+
+// CodecByName returns the codec with the given name, as returned
+// by its Name method.
+func CodecByName(name string) (Codec, bool) {
+	for _, c := range codecs {
+		if c.Name() == name {
+			return c, true
+		}
+	}
+
+	return nil, false
+}
+
+// CodecByExt returns the codec whose extension the file name ends
+// with.
+func CodecByExt(name string) (Codec, bool) {
+	for _, c := range codecs {
+		if strings.HasSuffix(name, c.Ext()) {
+			return c, true
+		}
+	}
+
+	return nil, false
+}
+
+type gzipCodec struct{}
+
+func (gzipCodec) Name() string { return "gzip" }
+func (gzipCodec) Ext() string  { return ".gz" }
+
+func (gzipCodec) NewWriter(w io.Writer, level CompressionLevel) (io.WriteCloser, error) {
+	l := gzip.DefaultCompression
+	switch level {
+	case LevelFastest:
+		l = gzip.BestSpeed
+	case LevelBetter:
+		l = 7
+	case LevelBest:
+		l = gzip.BestCompression
+	}
+
+	return gzip.NewWriterLevel(w, l)
+}
+
+func (gzipCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+type zstdCodec struct{}
+
+func (zstdCodec) Name() string { return "zstd" }
+func (zstdCodec) Ext() string  { return ".zst" }
+
+func (zstdCodec) NewWriter(w io.Writer, level CompressionLevel) (io.WriteCloser, error) {
+	l := zstd.SpeedDefault
+	switch level {
+	case LevelFastest:
+		l = zstd.SpeedFastest
+	case LevelBetter:
+		l = zstd.SpeedBetterCompression
+	case LevelBest:
+		l = zstd.SpeedBestCompression
+	}
+
+	return zstd.NewWriter(w, zstd.WithEncoderLevel(l))
+}
+
+func (zstdCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	d, err := zstd.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.IOReadCloser(), nil
+}
+
+// s2Options maps the compression level onto the s2 writer options,
+// the fastest level being the s2 default.
+func s2Options(level CompressionLevel) []s2.WriterOption {
+	switch level {
+	case LevelBetter:
+		return []s2.WriterOption{s2.WriterBetterCompression()}
+	case LevelBest:
+		return []s2.WriterOption{s2.WriterBestCompression()}
+	}
+
+	return nil
+}
+
+type s2Codec struct{}
+
+func (s2Codec) Name() string { return "s2" }
+func (s2Codec) Ext() string  { return ".s2" }
+
+func (s2Codec) NewWriter(w io.Writer, level CompressionLevel) (io.WriteCloser, error) {
+	return s2.NewWriter(w, s2Options(level)...), nil
+}
+
+func (s2Codec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return io.NopCloser(s2.NewReader(r)), nil
+}
+
+// snappyCodec writes the snappy framing format, which can be read
+// by any snappy implementation.
+type snappyCodec struct{}
+
+func (snappyCodec) Name() string { return "snappy" }
+func (snappyCodec) Ext() string  { return ".sz" }
+
+func (snappyCodec) NewWriter(w io.Writer, level CompressionLevel) (io.WriteCloser, error) {
+	opts := append(s2Options(level), s2.WriterSnappyCompat())
+	return s2.NewWriter(w, opts...), nil
+}
+
+func (snappyCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return io.NopCloser(s2.NewReader(r)), nil
+}
+
+type lz4Codec struct{}
+
+func (lz4Codec) Name() string { return "lz4" }
+func (lz4Codec) Ext() string  { return ".lz4" }
+
+func (lz4Codec) NewWriter(w io.Writer, level CompressionLevel) (io.WriteCloser, error) {
+	l := lz4.Fast
+	switch level {
+	case LevelBetter:
+		l = lz4.Level5
+	case LevelBest:
+		l = lz4.Level9
+	}
+
+	lw := lz4.NewWriter(w)
+
+	err := lw.Apply(lz4.CompressionLevelOption(l))
+	if err != nil {
+		return nil, err
+	}
+
+	return lw, nil
+}
+
+func (lz4Codec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return io.NopCloser(lz4.NewReader(r)), nil
+}
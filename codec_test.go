@@ -0,0 +1,51 @@
+package filewriter
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodecRoundTrip(t *testing.T) {
+	payload := bytes.Repeat([]byte("Hello, world!\n"), 1024)
+	levels := []CompressionLevel{LevelDefault, LevelFastest, LevelBetter, LevelBest}
+
+	for _, codec := range codecs {
+		for _, level := range levels {
+			var compressed bytes.Buffer
+
+			cw, err := codec.NewWriter(&compressed, level)
+			require.NoError(t, err, "%v: expected no error when creating writer, got '%v'", codec.Name(), err)
+
+			_, err = cw.Write(payload)
+			require.NoError(t, err, "%v: expected no error when compressing, got '%v'", codec.Name(), err)
+			require.NoError(t, cw.Close(), "%v: expected no error when closing writer", codec.Name())
+
+			cr, err := codec.NewReader(&compressed)
+			require.NoError(t, err, "%v: expected no error when creating reader, got '%v'", codec.Name(), err)
+
+			decompressed, err := io.ReadAll(cr)
+			require.NoError(t, err, "%v: expected no error when decompressing, got '%v'", codec.Name(), err)
+			cr.Close()
+
+			require.Equal(t, payload, decompressed, "%v: expected payload to survive round trip", codec.Name())
+		}
+	}
+}
+
+func TestCodecLookup(t *testing.T) {
+	for _, codec := range codecs {
+		c, ok := CodecByName(codec.Name())
+		require.True(t, ok, "expected codec '%v' to be found by name", codec.Name())
+		require.Equal(t, codec, c, "unexpected codec found by name")
+
+		c, ok = CodecByExt("test.log.2006-01-02" + codec.Ext())
+		require.True(t, ok, "expected codec '%v' to be found by extension", codec.Name())
+		require.Equal(t, codec, c, "unexpected codec found by extension")
+	}
+
+	_, ok := CodecByExt("test.log.2006-01-02")
+	require.False(t, ok, "expected no codec for an uncompressed file")
+}
@@ -0,0 +1,331 @@
+package filewriter
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// compressFile compresses the file src into a new file dest using
+// the given codec, fsyncing dest if sync is set. If compression fails
+// or is abandoned because ctx ended, the partially written dest is
+// removed, so that src stays the only copy of the data.
+func compressFile(ctx context.Context, fs Fs, src, dest string, mode os.FileMode, codec Codec, level CompressionLevel, sync bool) error {
+	err := func() error {
+		in, err := fs.Open(src)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+
+		out, err := fs.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+
+		cw, err := codec.NewWriter(out, level)
+		if err != nil {
+			return err
+		}
+
+		_, err = io.Copy(cw, ctxReader{ctx: ctx, r: in})
+		if err != nil {
+			cw.Close()
+			return err
+		}
+
+		err = cw.Close()
+		if err != nil || !sync {
+			return err
+		}
+
+		return out.Sync()
+	}()
+
+	if err != nil {
+		fs.Remove(dest)
+		return newError(OpCompress, src, err)
+	}
+
+	return nil
+}
+
+// compressJob describes a rotated log file waiting to be
+// compressed. Everything the job needs is copied out of the
+// FileWriter when it is created, so that it can be run without
+// holding fw.mu.
+type compressJob struct {
+	fs           Fs
+	src          string
+	mode         os.FileMode
+	codec        Codec
+	level        CompressionLevel
+	sync         bool
+	retention    retention
+	errorHandler func(fw *FileWriter, err error)
+	onCompressed []func(CompressEvent) error
+}
+
+// run compresses the backup and removes the uncompressed one. The
+// uncompressed backup is kept if compression fails or is abandoned
+// because ctx ended. The time spent and the sizes of both files are
+// recorded in m.
+func (job compressJob) run(ctx context.Context, m *metrics) CompressEvent {
+	event := CompressEvent{
+		Source: job.src,
+		Backup: job.src + job.codec.Ext(),
+		Codec:  job.codec.Name(),
+	}
+
+	src, err := job.fs.Stat(job.src)
+	if err != nil {
+		event.Err = newError(OpStat, job.src, err)
+		return event
+	}
+
+	start := time.Now()
+
+	err = compressFile(ctx, job.fs, job.src, event.Backup, job.mode, job.codec, job.level, job.sync)
+	if err != nil {
+		event.Err = err
+		return event
+	}
+
+	elapsed := time.Since(start)
+
+	out, err := job.fs.Stat(event.Backup)
+	if err == nil {
+		event.Size, event.CompressedSize = src.Size(), out.Size()
+		m.observeCompress(elapsed, event.Size, event.CompressedSize)
+	}
+
+	err = job.fs.Remove(job.src)
+	if err != nil {
+		event.Err = newError(OpRemove, job.src, err)
+		return event
+	}
+
+	if job.sync {
+		dir, _ := splitDir(job.src)
+		event.Err = syncDir(job.fs, dir)
+	}
+
+	return event
+}
+
+// complete reports a failed compression through the ErrorHandler
+// and queues the OnCompressed hooks.
+func (job compressJob) complete(fw *FileWriter, event CompressEvent) {
+	if event.Err != nil {
+		job.errorHandler(fw, fw.metrics.countError(errorKindCompress, event.Err))
+	}
+
+	queueHooks(fw, job.onCompressed, event, job.errorHandler)
+}
+
+// compressor is a pool of workers that compress rotated log files
+// in the background, so that rotation only has to rename the file
+// and writes can resume immediately on the fresh one.
+type compressor struct {
+	jobs chan compressJob
+	wg   sync.WaitGroup
+
+	// ctx is canceled to abandon the compressions in progress when
+	// CloseContext runs out of time.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu        sync.Mutex
+	abandoned []string // the backups left uncompressed after cancellation
+}
+
+func newCompressor(fw *FileWriter, workers, queueSize int) *compressor {
+	c := &compressor{jobs: make(chan compressJob, queueSize)}
+	c.ctx, c.cancel = context.WithCancel(context.Background())
+
+	c.wg.Add(workers)
+	for range workers {
+		go func() {
+			defer c.wg.Done()
+
+			for job := range c.jobs {
+				event := job.run(c.ctx, &fw.metrics)
+				if event.Err != nil && c.ctx.Err() != nil {
+					c.mu.Lock()
+					c.abandoned = append(c.abandoned, job.src)
+					c.mu.Unlock()
+				}
+
+				job.complete(fw, event)
+				fw.runHooks()
+
+				fw.prune(job.retention, job.errorHandler)
+			}
+		}()
+	}
+
+	return c
+}
+
+// enqueue hands the job over to the workers. It returns false
+// without blocking if the queue is full.
+func (c *compressor) enqueue(job compressJob) bool {
+	select {
+	case c.jobs <- job:
+		return true
+	default:
+		return false
+	}
+}
+
+// stop closes the queue. The workers exit after compressing every
+// job already queued; if wait is true, stop blocks until they do.
+func (c *compressor) stop(wait bool) {
+	c.stopContext(context.Background(), wait, nil)
+}
+
+// stopContext is like stop, but hands the pending jobs over to the
+// workers before the queue is closed, and if ctx ends before the
+// workers are done, the compressions in progress, the queued ones
+// and the pending ones not handed over yet are abandoned. It returns
+// the backups left uncompressed. If wait is false, the pending jobs
+// are handed over in the background.
+func (c *compressor) stopContext(ctx context.Context, wait bool, pending []compressJob) []string {
+	if !wait {
+		go func() {
+			for _, job := range pending {
+				c.jobs <- job
+			}
+			close(c.jobs)
+		}()
+
+		return nil
+	}
+
+	var abandoned []string
+	for i, job := range pending {
+		if ctx.Err() == nil {
+			select {
+			case c.jobs <- job:
+				continue
+			case <-ctx.Done():
+			}
+		}
+
+		for _, job := range pending[i:] {
+			abandoned = append(abandoned, job.src)
+		}
+		break
+	}
+
+	close(c.jobs)
+
+	done := make(chan struct{})
+	go func() {
+		c.wg.Wait()
+		close(done)
+	}()
+
+	if ctx.Err() != nil {
+		c.cancel()
+	}
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		c.cancel()
+		<-done
+	}
+
+	c.cancel()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append(c.abandoned, abandoned...)
+}
+
+func (fw *FileWriter) runCompressor() {
+	if !fw.Compress {
+		return
+	}
+
+	fw.compressor = newCompressor(fw, max(fw.CompressWorkers, 1), fw.CompressQueueSize)
+}
+
+// compressBackup hands the rotated log file over to the background
+// compressor. Compression never runs under fw.mu: if the queue is
+// full, the backup is left uncompressed and handed over by a later
+// rotation, tick or Close.
+func (fw *FileWriter) compressBackup(job compressJob) {
+	fw.pendingCompress = append(fw.pendingCompress, job)
+	fw.queuePendingCompress()
+}
+
+// queuePendingCompress hands the backups left uncompressed because
+// the queue was full over to the compressor, oldest first, for as
+// long as the queue has room. It must be called with fw.mu held.
+func (fw *FileWriter) queuePendingCompress() {
+	if fw.compressor == nil || fw.holdCompress {
+		return
+	}
+
+	for len(fw.pendingCompress) > 0 && fw.compressor.enqueue(fw.pendingCompress[0]) {
+		fw.pendingCompress = fw.pendingCompress[1:]
+	}
+
+	if len(fw.pendingCompress) == 0 {
+		fw.pendingCompress = nil
+	}
+}
+
+// CompressBackups compresses the uncompressed backups of the log
+// file with the given name with the codec and compression level set
+// by the options, e.g. backups left behind when a process exited
+// before its background compression finished. It must not be run
+// while a FileWriter may still be compressing the same backups. An
+// event is returned for every backup, including the failed ones.
+func CompressBackups(name string, opts ...Option) ([]CompressEvent, error) {
+	c, err := NewConfig(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	backups, err := listBackups(c.Fs, name, c.RotatePostfix)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		events []CompressEvent
+		errs   []error
+	)
+
+	for i := len(backups) - 1; i >= 0; i-- {
+		if _, ok := CodecByExt(backups[i].Path); ok {
+			continue
+		}
+
+		job := compressJob{
+			fs:    c.Fs,
+			src:   backups[i].Path,
+			mode:  c.Mode,
+			codec: c.Codec,
+			level: c.CompressLevel,
+			sync:  c.SyncMode != SyncNever,
+		}
+
+		event := job.run(context.Background(), &metrics{})
+		if event.Err != nil {
+			errs = append(errs, event.Err)
+		}
+
+		events = append(events, event)
+	}
+
+	return events, errors.Join(errs...)
+}
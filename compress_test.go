@@ -0,0 +1,115 @@
+package filewriter
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/suite"
+)
+
+type testCompressSuite struct {
+	suite.Suite
+
+	afs *afero.Afero
+
+	fileName    string
+	filePayload []byte
+}
+
+func TestCompressSuite(t *testing.T) {
+	tc := &testCompressSuite{
+		fileName:    "test.log.2006-01-02T15:04:05Z",
+		filePayload: []byte("Hello, world!\n"),
+	}
+
+	suite.Run(t, tc)
+}
+
+func (tc *testCompressSuite) SetupTest() {
+	tc.afs = &afero.Afero{Fs: afero.NewMemMapFs()}
+
+	err := tc.afs.WriteFile(tc.fileName, tc.filePayload, defaulFileMode)
+	tc.Require().NoError(err, "expected no error when writing file, got '%v'", err)
+}
+
+func (tc *testCompressSuite) requireCompressed() {
+	exists, err := tc.afs.Exists(tc.fileName)
+	tc.Require().NoError(err, "expected no error when checking file existence, got '%v'", err)
+	tc.Require().False(exists, "expected uncompressed backup to be removed")
+
+	f, err := tc.afs.Open(tc.fileName + ".gz")
+	tc.Require().NoError(err, "expected no error when opening compressed backup, got '%v'", err)
+	defer f.Close()
+
+	gr, err := gzip.NewReader(f)
+	tc.Require().NoError(err, "expected no error when reading gzip header, got '%v'", err)
+
+	payload, err := io.ReadAll(gr)
+	tc.Require().NoError(err, "expected no error when decompressing, got '%v'", err)
+
+	tc.Require().Equal(
+		tc.filePayload, payload,
+		"expected decompressed payload '%v', got '%v'",
+		string(tc.filePayload), string(payload),
+	)
+}
+
+func (tc *testCompressSuite) TestCompressJob() {
+	job := compressJob{fs: tc.afs, src: tc.fileName, mode: defaulFileMode, codec: Gzip}
+
+	event := job.run(context.Background(), &metrics{})
+	tc.Require().NoError(event.Err, "expected no error when compressing, got '%v'", event.Err)
+
+	tc.requireCompressed()
+}
+
+func (tc *testCompressSuite) TestCompressor() {
+	fw := &FileWriter{}
+
+	var handled error
+	job := compressJob{
+		fs:           tc.afs,
+		src:          tc.fileName,
+		mode:         defaulFileMode,
+		codec:        Gzip,
+		errorHandler: func(fw *FileWriter, err error) { handled = err },
+	}
+
+	c := newCompressor(fw, 1, 1)
+	tc.Require().True(c.enqueue(job), "expected job to be queued")
+	c.stop(true)
+
+	tc.Require().NoError(handled, "expected no error when compressing, got '%v'", handled)
+	tc.requireCompressed()
+}
+
+func (tc *testCompressSuite) TestCompressBackups() {
+	events, err := CompressBackups("test.log", WithFileSystem(tc.afs))
+	tc.Require().NoError(err, "expected no error when compressing backups, got '%v'", err)
+	tc.Require().Len(events, 1, "unexpected number of compressed backups")
+
+	tc.requireCompressed()
+}
+
+func (tc *testCompressSuite) TestCompressPending() {
+	// A compressor without workers never has room in its queue.
+	fw := &FileWriter{compressor: &compressor{jobs: make(chan compressJob)}}
+
+	job := compressJob{fs: tc.afs, src: tc.fileName, mode: defaulFileMode, codec: Gzip}
+	fw.compressBackup(job)
+
+	tc.Require().Len(fw.pendingCompress, 1, "expected the backup to wait for room in the queue")
+
+	exists, err := tc.afs.Exists(tc.fileName)
+	tc.Require().NoError(err, "expected no error when checking file existence, got '%v'", err)
+	tc.Require().True(exists, "expected the backup to be left uncompressed")
+
+	fw.compressor = newCompressor(fw, 1, 0)
+	uncompressed := fw.compressor.stopContext(context.Background(), true, fw.pendingCompress)
+	tc.Require().Empty(uncompressed, "expected every pending backup to be compressed")
+
+	tc.requireCompressed()
+}
@@ -0,0 +1,342 @@
+package filewriter
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// Config holds the settings of a FileWriter. It can be built and
+// checked with Validate before any file is opened, and is passed to
+// New with WithConfig. The FileWriter embeds its Config, so the
+// settings are also available as its fields.
+type Config struct {
+	Fs            Fs // the filesystem the log files are stored on
+	Mode          os.FileMode
+	Flags         int
+	DeleteOld     bool   // indicates whether the old log file should be removed after rotation
+	RotatePostfix string // the postfix added to the file name during log rotation
+	Compress      bool   // indicates whether the log file should be compressed
+	MaxSize       uint   // the maximum allowed size of the log file (in bytes)
+
+	SyncMode     SyncMode      // defines when the log file is fsynced
+	SyncBytes    uint          // the number of flushed bytes between fsyncs for SyncEveryBytes
+	SyncInterval time.Duration // the minimum time between fsyncs for SyncEveryInterval
+
+	Codec         Codec            // the codec used to compress rotated files
+	CompressLevel CompressionLevel // the compression level passed to the codec
+
+	// the number of workers compressing rotated files in the
+	// background, at least one worker is started
+	CompressWorkers   int
+	CompressQueueSize int  // the number of rotated files waiting for compression
+	WaitCompress      bool // indicates whether Close waits for pending compressions
+
+	MaxBackups   int           // the maximum number of backups to keep, 0 keeps all
+	MaxAge       time.Duration // the maximum age of a backup, 0 keeps backups forever
+	MaxTotalSize uint          // the maximum total size of backups (in bytes), 0 is unlimited
+
+	// indicates whether the log file is checked on every tick of the
+	// FlushTicker for being moved, deleted or truncated by another
+	// process, such as logrotate
+	WatchFile bool
+
+	// the capacity of the queue of records in asynchronous mode, 0
+	// makes Write synchronous
+	AsyncQueueSize int
+	// defines what an asynchronous Write does when the queue is full
+	OverflowPolicy OverflowPolicy
+	// the writer that receives records with OverflowSpill
+	SpillWriter io.Writer
+
+	// defines how a record larger than MaxSize is written
+	OversizePolicy OversizePolicy
+	// the marker ending a record truncated by OversizeTruncate
+	TruncateMarker []byte
+
+	// indicates whether every Write is stored as a frame carrying
+	// the length and the CRC-32C of the record, which can be read
+	// back with a FrameReader
+	Framed bool
+
+	// the hooks called after the log file is rotated, after a rotated
+	// file is compressed and after backups are pruned; they are run
+	// outside of the lock and their errors are reported through the
+	// ErrorHandler
+	OnRotate     []func(RotateEvent) error
+	OnCompressed []func(CompressEvent) error
+	OnPruned     []func(PruneEvent) error
+
+	// the policy that decides when the log file is rotated
+	RotatePolicy RotatePolicy
+
+	BufSize       int           // the capacity of the buffer (in bytes)
+	MaxBatchSize  int           // the number of log entries that triggers a flush, 0 disables it
+	FlushBytes    uint          // the number of buffered bytes that triggers a flush, 0 waits for a full buffer
+	FlushLatency  time.Duration // the maximum time data waits in the buffer, 0 leaves it to FlushTicker
+	FlushInterval time.Duration // the interval of the FlushTicker, 0 disables periodic flushes
+
+	// the predicate reporting whether a record is urgent, i.e. must
+	// be flushed right after it is written
+	Urgent func(p []byte) bool
+
+	// the function to handle errors that occur during flushing
+	ErrorHandler func(fw *FileWriter, err error)
+}
+
+// DefaultConfig returns the Config a FileWriter is created with when
+// no options are given.
+func DefaultConfig() Config {
+	return Config{
+		Fs:            afero.NewOsFs(),
+		Mode:          defaulFileMode,
+		Flags:         defaulFileFlags,
+		DeleteOld:     defaultFileDeleteOld,
+		RotatePostfix: defaultFileRotatePostfix,
+		Compress:      defaulFileCompress,
+		MaxSize:       defaulFileMaxSize,
+		SyncMode:      defaultSyncMode,
+
+		Codec:         Gzip,
+		CompressLevel: LevelDefault,
+
+		CompressWorkers:   defaultCompressWorkers,
+		CompressQueueSize: defaultCompressQueueSize,
+		WaitCompress:      defaultWaitCompress,
+
+		MaxBackups:   defaultFileMaxBackups,
+		MaxAge:       defaultFileMaxAge,
+		MaxTotalSize: defaultFileMaxTotalSize,
+		RotatePolicy: SizePolicy{},
+
+		OversizePolicy: OversizeIsolate,
+		TruncateMarker: []byte(defaultTruncateMarker),
+
+		BufSize:       defaultBufSize,
+		MaxBatchSize:  defaulBufMaxBatchSize,
+		FlushInterval: defaulBufFlushInterval,
+		ErrorHandler:  func(fw *FileWriter, err error) {},
+	}
+}
+
+// NewConfig applies the options to the DefaultConfig and validates
+// the result. The error lists every invalid option and setting.
+func NewConfig(opts ...Option) (Config, error) {
+	return applyOptions(DefaultConfig(), opts...)
+}
+
+// applyOptions applies the options to c and validates the result.
+func applyOptions(c Config, opts ...Option) (Config, error) {
+	var errs []error
+	for _, opt := range opts {
+		err := opt(&c)
+
+		var configErr *ConfigError
+		if errors.As(err, &configErr) {
+			errs = append(errs, configErr.Errs...)
+		} else if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	// A rejected option leaves its setting unchanged, so it isn't
+	// reported twice.
+	errs = append(errs, c.validate()...)
+	if len(errs) > 0 {
+		return c, &ConfigError{Errs: errs}
+	}
+
+	return c, nil
+}
+
+// Validate checks the settings for values the FileWriter can't work
+// with. It returns a *ConfigError listing every invalid setting, or
+// nil if there is none.
+func (c Config) Validate() error {
+	errs := c.validate()
+	if len(errs) > 0 {
+		return &ConfigError{Errs: errs}
+	}
+
+	return nil
+}
+
+func (c Config) validate() []error {
+	var errs []error
+	invalid := func(setting string, value any, reason string) {
+		errs = append(errs, &SettingError{Setting: setting, Value: value, Reason: reason})
+	}
+
+	if c.Fs == nil {
+		invalid("Fs", nil, "must be set")
+	}
+
+	if c.Mode&^os.ModePerm != 0 {
+		invalid("Mode", c.Mode, "must only contain permission bits")
+	}
+
+	if c.Flags&(os.O_WRONLY|os.O_RDWR) == 0 {
+		invalid("Flags", c.Flags, "must open the file for writing")
+	}
+
+	// The torn frame of a framed log is found by reading the file
+	// when it's opened.
+	if c.Framed && c.Flags&os.O_RDWR == 0 {
+		invalid("Flags", c.Flags, "must include os.O_RDWR when Framed is set")
+	}
+
+	reason := validatePostfix(c.RotatePostfix)
+	if reason != "" {
+		invalid("RotatePostfix", c.RotatePostfix, reason)
+	}
+
+	if c.MaxSize == 0 {
+		invalid("MaxSize", c.MaxSize, "must be positive")
+	}
+
+	if c.SyncMode < SyncNever || c.SyncMode > SyncEveryInterval {
+		invalid("SyncMode", c.SyncMode, "unknown sync mode")
+	}
+
+	if c.SyncInterval < 0 {
+		invalid("SyncInterval", c.SyncInterval, "must not be negative")
+	}
+
+	if c.Compress && c.Codec == nil {
+		invalid("Codec", nil, "must be set when compression is enabled")
+	}
+
+	if c.CompressLevel < LevelDefault || c.CompressLevel > LevelBest {
+		invalid("CompressLevel", c.CompressLevel, "unknown compression level")
+	}
+
+	if c.CompressWorkers < 0 {
+		invalid("CompressWorkers", c.CompressWorkers, "must not be negative")
+	}
+
+	if c.CompressQueueSize < 0 {
+		invalid("CompressQueueSize", c.CompressQueueSize, "must not be negative")
+	}
+
+	if c.MaxBackups < 0 {
+		invalid("MaxBackups", c.MaxBackups, "must not be negative")
+	}
+
+	if c.MaxAge < 0 {
+		invalid("MaxAge", c.MaxAge, "must not be negative")
+	}
+
+	if c.AsyncQueueSize < 0 {
+		invalid("AsyncQueueSize", c.AsyncQueueSize, "must not be negative")
+	}
+
+	if c.OverflowPolicy < OverflowBlock || c.OverflowPolicy > OverflowSpill {
+		invalid("OverflowPolicy", c.OverflowPolicy, "unknown overflow policy")
+	}
+
+	if c.OverflowPolicy == OverflowSpill && c.SpillWriter == nil {
+		invalid("SpillWriter", nil, "must be set for OverflowSpill")
+	}
+
+	if c.OversizePolicy < OversizeIsolate || c.OversizePolicy > OversizeTruncate {
+		invalid("OversizePolicy", c.OversizePolicy, "unknown oversize policy")
+	}
+
+	if c.RotatePolicy == nil {
+		invalid("RotatePolicy", nil, "must be set")
+	}
+
+	if c.BufSize < 0 {
+		invalid("BufSize", c.BufSize, "must not be negative")
+	}
+
+	if c.MaxBatchSize < 0 {
+		invalid("MaxBatchSize", c.MaxBatchSize, "must not be negative")
+	}
+
+	if c.FlushLatency < 0 {
+		invalid("FlushLatency", c.FlushLatency, "must not be negative")
+	}
+
+	if c.FlushInterval < 0 {
+		invalid("FlushInterval", c.FlushInterval, "must not be negative")
+	}
+
+	// The log file is checked on the ticks of the FlushTicker.
+	if c.WatchFile && c.FlushInterval == 0 {
+		invalid("WatchFile", c.WatchFile, "requires a positive FlushInterval")
+	}
+
+	if c.ErrorHandler == nil {
+		invalid("ErrorHandler", nil, "must be set")
+	}
+
+	return errs
+}
+
+// validatePostfix returns why the postfix can't be used to name the
+// backups, or an empty string if it can. The postfix is a time
+// layout, so it is checked formatted as well.
+func validatePostfix(postfix string) string {
+	if postfix == "" {
+		return "must not be empty"
+	}
+
+	formatted := time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC).Format(postfix)
+	if strings.ContainsAny(postfix, `/\`) || strings.ContainsAny(formatted, `/\`) {
+		return "must not contain a path separator"
+	}
+
+	return ""
+}
+
+// ErrInvalidConfig matches every *ConfigError with errors.Is.
+var ErrInvalidConfig = errors.New("invalid file writer configuration")
+
+// ConfigError is returned by New, NewConfig and Config.Validate for
+// an invalid configuration. It lists every invalid option and
+// setting, which are usually *SettingError values.
+type ConfigError struct {
+	Errs []error
+}
+
+func (e *ConfigError) Error() string {
+	msgs := make([]string, len(e.Errs))
+	for i, err := range e.Errs {
+		msgs[i] = err.Error()
+	}
+
+	return ErrInvalidConfig.Error() + ": " + strings.Join(msgs, "; ")
+}
+
+func (e *ConfigError) Unwrap() []error {
+	return e.Errs
+}
+
+func (e *ConfigError) Is(target error) bool {
+	return target == ErrInvalidConfig
+}
+
+// SettingError describes an invalid setting of a Config.
+type SettingError struct {
+	Setting string // the name of the Config field, or the key of an unknown setting
+	Value   any
+	Reason  string
+}
+
+func (e *SettingError) Error() string {
+	if s, ok := e.Value.(string); ok {
+		return fmt.Sprintf("%s %q: %s", e.Setting, s, e.Reason)
+	}
+
+	if e.Value == nil {
+		return e.Setting + ": " + e.Reason
+	}
+
+	return fmt.Sprintf("%s %v: %s", e.Setting, e.Value, e.Reason)
+}
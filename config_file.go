@@ -0,0 +1,526 @@
+package filewriter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// setting maps a key of a configuration file, and of an environment
+// variable, onto a field of the Config.
+type setting struct {
+	key   string // the key in a file, e.g. "max_size"
+	field string // the name of the Config field, e.g. "MaxSize"
+	set   func(c *Config, s string) error
+}
+
+// settings lists every setting that can be loaded. Hooks, writers
+// and other values that can't be written down are only set with
+// options.
+var settings = []setting{
+	{"mode", "Mode", func(c *Config, s string) error {
+		mode, err := strconv.ParseUint(strings.TrimPrefix(s, "0o"), 8, 32)
+		if err != nil {
+			return errors.New("must be an octal number such as 0644")
+		}
+
+		c.Mode = os.FileMode(mode)
+		return nil
+	}},
+	{"delete_old", "DeleteOld", setBool(func(c *Config) *bool { return &c.DeleteOld })},
+	{"rotate_postfix", "RotatePostfix", func(c *Config, s string) error {
+		c.RotatePostfix = s
+		return nil
+	}},
+	{"compress", "Compress", setBool(func(c *Config) *bool { return &c.Compress })},
+	{"max_size", "MaxSize", setSizeWithUnit(func(c *Config) *uint { return &c.MaxSize })},
+	{"rotate_interval", "RotatePolicy", func(c *Config, s string) error {
+		interval, err := ParseDuration(s)
+		if err != nil {
+			return errInvalidDuration
+		}
+
+		c.RotatePolicy = withPolicy(c.RotatePolicy, IntervalPolicy{Interval: interval})
+		return nil
+	}},
+	{"rotate_at", "RotatePolicy", func(c *Config, s string) error {
+		every, err := parseName(s, map[string]Boundary{"hour": BoundaryHour, "day": BoundaryDay})
+		if err != nil {
+			return err
+		}
+
+		c.RotatePolicy = withPolicy(c.RotatePolicy, BoundaryPolicy{Every: every})
+		return nil
+	}},
+	// rotate_tz follows rotate_at, so that it applies to the boundary
+	// set in the same file.
+	{"rotate_tz", "RotatePolicy", func(c *Config, s string) error {
+		loc, err := time.LoadLocation(s)
+		if err != nil {
+			return errors.New("unknown time zone")
+		}
+
+		p, ok := findPolicy[BoundaryPolicy](c.RotatePolicy)
+		if !ok {
+			return errors.New("requires rotate_at")
+		}
+
+		p.Location = loc
+		c.RotatePolicy = withPolicy(c.RotatePolicy, p)
+		return nil
+	}},
+
+	{"sync_mode", "SyncMode", func(c *Config, s string) error {
+		mode, err := parseName(s, map[string]SyncMode{
+			"never":          SyncNever,
+			"on_rotate":      SyncOnRotate,
+			"on_flush":       SyncOnFlush,
+			"every_bytes":    SyncEveryBytes,
+			"every_interval": SyncEveryInterval,
+		})
+		if err != nil {
+			return err
+		}
+
+		c.SyncMode = mode
+		return nil
+	}},
+	{"sync_bytes", "SyncBytes", setSize(func(c *Config) *uint { return &c.SyncBytes })},
+	{"sync_interval", "SyncInterval", setDuration(func(c *Config) *time.Duration { return &c.SyncInterval })},
+
+	{"codec", "Codec", func(c *Config, s string) error {
+		codec, ok := CodecByName(s)
+		if !ok {
+			return errors.New("unknown codec")
+		}
+
+		c.Codec = codec
+		return nil
+	}},
+	{"compress_level", "CompressLevel", func(c *Config, s string) error {
+		level, err := parseName(s, map[string]CompressionLevel{
+			"default": LevelDefault,
+			"fastest": LevelFastest,
+			"better":  LevelBetter,
+			"best":    LevelBest,
+		})
+		if err != nil {
+			return err
+		}
+
+		c.CompressLevel = level
+		return nil
+	}},
+	{"compress_workers", "CompressWorkers", setInt(func(c *Config) *int { return &c.CompressWorkers })},
+	{"compress_queue_size", "CompressQueueSize", setInt(func(c *Config) *int { return &c.CompressQueueSize })},
+	{"wait_compress", "WaitCompress", setBool(func(c *Config) *bool { return &c.WaitCompress })},
+
+	{"max_backups", "MaxBackups", setInt(func(c *Config) *int { return &c.MaxBackups })},
+	{"max_age", "MaxAge", setDuration(func(c *Config) *time.Duration { return &c.MaxAge })},
+	{"max_total_size", "MaxTotalSize", setSizeWithUnit(func(c *Config) *uint { return &c.MaxTotalSize })},
+
+	{"watch_file", "WatchFile", setBool(func(c *Config) *bool { return &c.WatchFile })},
+
+	{"async_queue_size", "AsyncQueueSize", setInt(func(c *Config) *int { return &c.AsyncQueueSize })},
+	{"overflow_policy", "OverflowPolicy", func(c *Config, s string) error {
+		policy, err := parseName(s, map[string]OverflowPolicy{
+			"block":       OverflowBlock,
+			"drop_newest": OverflowDropNewest,
+			"drop_oldest": OverflowDropOldest,
+			"spill":       OverflowSpill,
+		})
+		if err != nil {
+			return err
+		}
+
+		c.OverflowPolicy = policy
+		return nil
+	}},
+
+	{"oversize_policy", "OversizePolicy", func(c *Config, s string) error {
+		policy, err := parseName(s, map[string]OversizePolicy{
+			"isolate":  OversizeIsolate,
+			"reject":   OversizeReject,
+			"truncate": OversizeTruncate,
+		})
+		if err != nil {
+			return err
+		}
+
+		c.OversizePolicy = policy
+		return nil
+	}},
+	{"truncate_marker", "TruncateMarker", func(c *Config, s string) error {
+		c.TruncateMarker = []byte(s)
+		return nil
+	}},
+
+	{"framed", "Framed", setBool(func(c *Config) *bool { return &c.Framed })},
+
+	{"buf_size", "BufSize", func(c *Config, s string) error {
+		size, err := ParseSize(s)
+		if err != nil {
+			return errInvalidSize
+		}
+
+		c.BufSize = int(size)
+		return nil
+	}},
+	{"max_batch_size", "MaxBatchSize", setInt(func(c *Config) *int { return &c.MaxBatchSize })},
+	{"flush_bytes", "FlushBytes", setSize(func(c *Config) *uint { return &c.FlushBytes })},
+	{"flush_latency", "FlushLatency", setDuration(func(c *Config) *time.Duration { return &c.FlushLatency })},
+	{"flush_interval", "FlushInterval", setDuration(func(c *Config) *time.Duration { return &c.FlushInterval })},
+}
+
+var (
+	errInvalidSize     = errors.New("must be a size such as 4096 or 64MiB")
+	errSizeWithoutUnit = errors.New("must be a size with a unit such as 64MiB, a bare number is ambiguous since the options take megabytes")
+	errInvalidDuration = errors.New("must be a duration such as 90s, 24h or 7d")
+)
+
+func setBool(field func(c *Config) *bool) func(c *Config, s string) error {
+	return func(c *Config, s string) error {
+		v, err := strconv.ParseBool(s)
+		if err != nil {
+			return errors.New("must be true or false")
+		}
+
+		*field(c) = v
+		return nil
+	}
+}
+
+func setInt(field func(c *Config) *int) func(c *Config, s string) error {
+	return func(c *Config, s string) error {
+		v, err := strconv.Atoi(s)
+		if err != nil {
+			return errors.New("must be an integer")
+		}
+
+		*field(c) = v
+		return nil
+	}
+}
+
+func setSize(field func(c *Config) *uint) func(c *Config, s string) error {
+	return func(c *Config, s string) error {
+		v, err := ParseSize(s)
+		if err != nil {
+			return errInvalidSize
+		}
+
+		*field(c) = v
+		return nil
+	}
+}
+
+// setSizeWithUnit is like setSize, but rejects a bare number, which
+// the options of the same setting take in megabytes rather than
+// bytes.
+func setSizeWithUnit(field func(c *Config) *uint) func(c *Config, s string) error {
+	return func(c *Config, s string) error {
+		s = strings.TrimSpace(s)
+		if s != "" && strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' }) < 0 {
+			return errSizeWithoutUnit
+		}
+
+		return setSize(field)(c, s)
+	}
+}
+
+func setDuration(field func(c *Config) *time.Duration) func(c *Config, s string) error {
+	return func(c *Config, s string) error {
+		v, err := ParseDuration(s)
+		if err != nil {
+			return errInvalidDuration
+		}
+
+		*field(c) = v
+		return nil
+	}
+}
+
+// parseName returns the value with the given name, ignoring case.
+func parseName[T any](s string, values map[string]T) (T, error) {
+	v, ok := values[strings.ToLower(s)]
+	if !ok {
+		names := make([]string, 0, len(values))
+		for name := range values {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+
+		return v, fmt.Errorf("must be one of %s", strings.Join(names, ", "))
+	}
+
+	return v, nil
+}
+
+// withPolicy returns a policy rotating the log file when either cur
+// or p asks for it. A policy of the same type as p is replaced, so
+// that a setting loaded twice, e.g. from a file and from the
+// environment, takes effect once.
+func withPolicy(cur, p RotatePolicy) RotatePolicy {
+	var policies AnyPolicy
+	if all, ok := cur.(AnyPolicy); ok {
+		policies = slices.Clone(all)
+	} else if cur != nil {
+		policies = AnyPolicy{cur}
+	}
+
+	for i, q := range policies {
+		if reflect.TypeOf(q) == reflect.TypeOf(p) {
+			policies[i] = p
+			return policies
+		}
+	}
+
+	return append(policies, p)
+}
+
+// findPolicy returns the policy of type P, either cur itself or one
+// of the policies combined in it with AnyPolicy.
+func findPolicy[P RotatePolicy](cur RotatePolicy) (P, bool) {
+	if p, ok := cur.(P); ok {
+		return p, true
+	}
+
+	if all, ok := cur.(AnyPolicy); ok {
+		for _, q := range all {
+			if p, ok := q.(P); ok {
+				return p, true
+			}
+		}
+	}
+
+	var zero P
+	return zero, false
+}
+
+var sizeUnits = map[string]float64{
+	"":    1,
+	"b":   1,
+	"kb":  1e3,
+	"mb":  1e6,
+	"gb":  1e9,
+	"tb":  1e12,
+	"kib": 1 << 10,
+	"mib": 1 << 20,
+	"gib": 1 << 30,
+	"tib": 1 << 40,
+}
+
+// ParseSize parses a size in bytes such as "4096", "64MiB" or
+// "1.5 GB". KB, MB, GB and TB are powers of 1000, while KiB, MiB,
+// GiB and TiB are powers of 1024. The unit is case-insensitive. A
+// size that doesn't fit into a uint is rejected.
+func ParseSize(s string) (uint, error) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if i < 0 {
+		i = len(s)
+	}
+
+	unit, ok := sizeUnits[strings.ToLower(strings.TrimSpace(s[i:]))]
+	if !ok {
+		return 0, fmt.Errorf("invalid size %q: unknown unit", s)
+	}
+
+	n, err := strconv.ParseFloat(s[:i], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+
+	size := n * unit
+	if size >= math.MaxUint {
+		return 0, fmt.Errorf("invalid size %q: too large", s)
+	}
+
+	return uint(size), nil
+}
+
+// ParseDuration parses a duration like time.ParseDuration, such as
+// "24h" or "1h30m", and also accepts days, such as "7d".
+func ParseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+
+	days, ok := strings.CutSuffix(s, "d")
+	if !ok {
+		return time.ParseDuration(s)
+	}
+
+	n, err := strconv.ParseFloat(days, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+
+	d := n * float64(24*time.Hour)
+	if math.Abs(d) >= math.MaxInt64 {
+		return 0, fmt.Errorf("invalid duration %q: too large", s)
+	}
+
+	return time.Duration(d), nil
+}
+
+// set applies the settings with the given keys. Every invalid or
+// unknown setting is reported in the returned *ConfigError.
+func (c *Config) set(values map[string]string) error {
+	var errs []error
+
+	for _, s := range settings {
+		v, ok := values[s.key]
+		if !ok {
+			continue
+		}
+
+		err := s.set(c, v)
+		if err != nil {
+			errs = append(errs, &SettingError{Setting: s.field, Value: v, Reason: err.Error()})
+		}
+	}
+
+	var unknown []string
+	for key := range values {
+		if !slices.ContainsFunc(settings, func(s setting) bool { return s.key == key }) {
+			unknown = append(unknown, key)
+		}
+	}
+	slices.Sort(unknown)
+
+	for _, key := range unknown {
+		errs = append(errs, &SettingError{Setting: key, Reason: "unknown setting"})
+	}
+
+	if len(errs) > 0 {
+		return &ConfigError{Errs: errs}
+	}
+
+	return nil
+}
+
+// configValue is a scalar of a configuration file. It is kept as
+// text, so that it is parsed the same way as an environment
+// variable.
+type configValue string
+
+func (v *configValue) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '"' {
+		return json.Unmarshal(data, (*string)(v))
+	}
+
+	if len(data) == 0 || data[0] == '{' || data[0] == '[' || string(data) == "null" {
+		return fmt.Errorf("invalid value %s: must be a string, a number or a boolean", data)
+	}
+
+	*v = configValue(data)
+	return nil
+}
+
+func (v *configValue) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind != yaml.ScalarNode {
+		return fmt.Errorf("invalid value at line %d: must be a string, a number or a boolean", n.Line)
+	}
+
+	*v = configValue(n.Value)
+	return nil
+}
+
+func setValues(c *Config, values map[string]configValue) error {
+	m := make(map[string]string, len(values))
+	for key, v := range values {
+		m[key] = string(v)
+	}
+
+	return c.set(m)
+}
+
+// UnmarshalJSON sets the settings present in a JSON object, such as
+// {"max_size": "64MiB", "max_age": "7d"}. The other settings keep
+// their values, so the Config is usually a DefaultConfig.
+func (c *Config) UnmarshalJSON(data []byte) error {
+	var values map[string]configValue
+
+	err := json.Unmarshal(data, &values)
+	if err != nil {
+		return err
+	}
+
+	return setValues(c, values)
+}
+
+// UnmarshalYAML is like UnmarshalJSON for a YAML mapping.
+func (c *Config) UnmarshalYAML(n *yaml.Node) error {
+	var values map[string]configValue
+
+	err := n.Decode(&values)
+	if err != nil {
+		return err
+	}
+
+	return setValues(c, values)
+}
+
+// WithConfigFile sets the settings present in a JSON or YAML file,
+// chosen by the extension of its path: .json, .yaml or .yml. The
+// keys are the snake-cased names of the Config fields, e.g.
+// max_size; sizes are written like "64MiB" and durations like "24h".
+func WithConfigFile(path string) Option {
+	return func(c *Config) error {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return newError(OpLoad, path, err)
+		}
+
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".json":
+			err = json.Unmarshal(data, c)
+		case ".yaml", ".yml":
+			err = yaml.Unmarshal(data, c)
+		default:
+			err = errors.New("unknown configuration file format")
+		}
+
+		var configErr *ConfigError
+		if err != nil && !errors.As(err, &configErr) {
+			return newError(OpLoad, path, err)
+		}
+
+		return err
+	}
+}
+
+// WithEnv sets the settings present in the environment. The name of
+// the variable is the upper-cased key of the setting in a
+// configuration file, prefixed with prefix and an underscore, e.g.
+// APP_LOG_MAX_SIZE=64MiB for the prefix "APP_LOG".
+func WithEnv(prefix string) Option {
+	return func(c *Config) error {
+		if prefix != "" && !strings.HasSuffix(prefix, "_") {
+			prefix += "_"
+		}
+
+		values := make(map[string]string)
+		for _, s := range settings {
+			v, ok := os.LookupEnv(prefix + strings.ToUpper(s.key))
+			if ok {
+				values[s.key] = v
+			}
+		}
+
+		return c.set(values)
+	}
+}
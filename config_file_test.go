@@ -0,0 +1,192 @@
+package filewriter
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		s        string
+		expected uint
+		valid    bool
+	}{
+		{s: "4096", expected: 4096, valid: true},
+		{s: "64MiB", expected: 64 << 20, valid: true},
+		{s: "1.5 GiB", expected: 3 << 29, valid: true},
+		{s: "10kb", expected: 10_000, valid: true},
+		{s: "2MB", expected: 2_000_000, valid: true},
+		{s: "64XB", valid: false},
+		{s: "MiB", valid: false},
+		{s: "-1", valid: false},
+		{s: "20000000TB", valid: false},
+	}
+
+	for _, tt := range tests {
+		size, err := ParseSize(tt.s)
+		if !tt.valid {
+			require.Error(t, err, "expected an error when parsing %q", tt.s)
+			continue
+		}
+
+		require.NoError(t, err, "expected no error when parsing %q, got '%v'", tt.s, err)
+		require.Equal(t, tt.expected, size, "unexpected size of %q", tt.s)
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		s        string
+		expected time.Duration
+		valid    bool
+	}{
+		{s: "24h", expected: 24 * time.Hour, valid: true},
+		{s: "1h30m", expected: 90 * time.Minute, valid: true},
+		{s: "7d", expected: 7 * 24 * time.Hour, valid: true},
+		{s: "0", expected: 0, valid: true},
+		{s: "10", valid: false},
+		{s: "d", valid: false},
+		{s: "200000d", valid: false},
+		{s: "-200000d", valid: false},
+	}
+
+	for _, tt := range tests {
+		d, err := ParseDuration(tt.s)
+		if !tt.valid {
+			require.Error(t, err, "expected an error when parsing %q", tt.s)
+			continue
+		}
+
+		require.NoError(t, err, "expected no error when parsing %q, got '%v'", tt.s, err)
+		require.Equal(t, tt.expected, d, "unexpected duration of %q", tt.s)
+	}
+}
+
+func writeConfigFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+
+	err := os.WriteFile(path, []byte(content), 0644)
+	require.NoError(t, err, "expected no error when writing config file, got '%v'", err)
+
+	return path
+}
+
+func TestWithConfigFileYAML(t *testing.T) {
+	path := writeConfigFile(t, "fw.yaml", `
+mode: "0640"
+max_size: 64MiB
+max_age: 7d
+max_backups: 10
+codec: zstd
+compress_level: best
+sync_mode: on_flush
+rotate_at: day
+rotate_tz: UTC
+flush_interval: 1s
+`)
+
+	c, err := NewConfig(WithConfigFile(path))
+	require.NoError(t, err, "expected no error when loading config, got '%v'", err)
+
+	require.Equal(t, os.FileMode(0640), c.Mode, "unexpected mode")
+	require.Equal(t, uint(64<<20), c.MaxSize, "unexpected max size")
+	require.Equal(t, 7*24*time.Hour, c.MaxAge, "unexpected max age")
+	require.Equal(t, 10, c.MaxBackups, "unexpected max backups")
+	require.Equal(t, Zstd, c.Codec, "unexpected codec")
+	require.Equal(t, LevelBest, c.CompressLevel, "unexpected compression level")
+	require.Equal(t, SyncOnFlush, c.SyncMode, "unexpected sync mode")
+	require.Equal(t, time.Second, c.FlushInterval, "unexpected flush interval")
+	require.Equal(
+		t, AnyPolicy{SizePolicy{}, BoundaryPolicy{Every: BoundaryDay, Location: time.UTC}}, c.RotatePolicy,
+		"expected rotation on size and at midnight UTC",
+	)
+}
+
+func TestWithConfigFileJSONInvalid(t *testing.T) {
+	path := writeConfigFile(t, "fw.json", `{"max_size": "64XB", "max_backups": 3, "max_sizes": 1}`)
+
+	_, err := NewConfig(WithConfigFile(path))
+
+	var configErr *ConfigError
+	require.ErrorAs(t, err, &configErr, "expected a *ConfigError, got '%v'", err)
+	require.Len(t, configErr.Errs, 2, "expected the invalid size and the unknown key to be reported")
+	require.ErrorContains(t, err, `MaxSize "64XB"`, "expected the invalid setting to be named")
+	require.ErrorContains(t, err, "max_sizes: unknown setting", "expected the unknown key to be named")
+}
+
+func TestWithConfigFileSizeWithoutUnit(t *testing.T) {
+	path := writeConfigFile(t, "fw.yaml", "max_size: 64\nmax_total_size: 1GiB\nsync_bytes: 4096\n")
+
+	_, err := NewConfig(WithConfigFile(path))
+
+	var configErr *ConfigError
+	require.ErrorAs(t, err, &configErr, "expected a *ConfigError, got '%v'", err)
+	require.Len(t, configErr.Errs, 1, "expected only the size without a unit to be reported")
+	require.ErrorContains(t, err, "MaxSize", "expected the setting to be named")
+}
+
+func TestWithConfigFileTimeZone(t *testing.T) {
+	path := writeConfigFile(t, "fw.yaml", "rotate_tz: UTC\n")
+
+	_, err := NewConfig(WithConfigFile(path))
+	require.ErrorContains(t, err, "requires rotate_at", "expected a time zone without a boundary to be rejected")
+
+	path = writeConfigFile(t, "fw.yaml", "rotate_at: hour\nrotate_tz: Mars/Olympus\n")
+
+	_, err = NewConfig(WithConfigFile(path))
+	require.ErrorContains(t, err, "unknown time zone", "expected an unknown time zone to be rejected")
+}
+
+func TestWithConfigFileMissing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fw.yaml")
+
+	_, err := NewConfig(WithConfigFile(path))
+	require.ErrorIs(t, err, os.ErrNotExist, "expected a missing file error, got '%v'", err)
+
+	var e *Error
+	require.ErrorAs(t, err, &e, "expected an *Error, got '%v'", err)
+	require.Equal(t, OpLoad, e.Op, "unexpected operation")
+}
+
+func TestWithEnv(t *testing.T) {
+	path := writeConfigFile(t, "fw.json", `{"max_size": "1MiB", "compress": true, "rotate_interval": "1h"}`)
+
+	t.Setenv("APP_LOG_MAX_SIZE", "1GiB")
+	t.Setenv("APP_LOG_COMPRESS", "false")
+	t.Setenv("APP_LOG_ROTATE_INTERVAL", "24h")
+
+	c, err := NewConfig(WithConfigFile(path), WithEnv("APP_LOG"))
+	require.NoError(t, err, "expected no error when loading config, got '%v'", err)
+
+	require.Equal(t, uint(1<<30), c.MaxSize, "expected the environment to override the file")
+	require.False(t, c.Compress, "expected the environment to override the file")
+	require.Equal(
+		t, AnyPolicy{SizePolicy{}, IntervalPolicy{Interval: 24 * time.Hour}}, c.RotatePolicy,
+		"expected the interval to be replaced, not added",
+	)
+
+	t.Setenv("APP_LOG_MAX_BACKUPS", "many")
+
+	_, err = NewConfig(WithEnv("APP_LOG"))
+	require.ErrorIs(t, err, ErrInvalidConfig, "expected an invalid variable to be reported, got '%v'", err)
+}
+
+func TestConfigUnmarshalEmbedded(t *testing.T) {
+	settings := struct {
+		Log Config `json:"log"`
+	}{Log: DefaultConfig()}
+
+	err := json.Unmarshal([]byte(`{"log": {"max_total_size": "1GB", "watch_file": true}}`), &settings)
+	require.NoError(t, err, "expected no error when unmarshalling, got '%v'", err)
+
+	require.Equal(t, uint(1e9), settings.Log.MaxTotalSize, "unexpected max total size")
+	require.True(t, settings.Log.WatchFile, "expected file watching to be enabled")
+
+	err = settings.Log.Validate()
+	require.NoError(t, err, "expected the unmarshalled config to be valid, got '%v'", err)
+}
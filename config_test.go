@@ -0,0 +1,132 @@
+package filewriter
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultConfigValid(t *testing.T) {
+	err := DefaultConfig().Validate()
+	require.NoError(t, err, "expected the default config to be valid, got '%v'", err)
+}
+
+func TestNewInvalidOptions(t *testing.T) {
+	afs := &afero.Afero{Fs: afero.NewMemMapFs()}
+
+	_, err := New(
+		"test.log",
+		WithFileSystem(afs),
+		WithFileMaxSize(-1),
+		WithLogMaxBatchSize(-1),
+		WithFileRotatePostfix(""),
+	)
+	require.ErrorIs(t, err, ErrInvalidConfig, "expected a config error, got '%v'", err)
+
+	var configErr *ConfigError
+	require.ErrorAs(t, err, &configErr, "expected a *ConfigError, got '%v'", err)
+
+	var settings []string
+	for _, err := range configErr.Errs {
+		var settingErr *SettingError
+		require.True(t, errors.As(err, &settingErr), "expected a *SettingError, got '%v'", err)
+		settings = append(settings, settingErr.Setting)
+	}
+
+	require.ElementsMatch(
+		t, []string{"MaxSize", "MaxBatchSize", "RotatePostfix"}, settings,
+		"expected every invalid setting to be reported",
+	)
+
+	exists, _ := afs.Exists("test.log")
+	require.False(t, exists, "expected no file to be opened for an invalid config")
+}
+
+func TestValidatePostfix(t *testing.T) {
+	tests := []struct {
+		postfix string
+		valid   bool
+	}{
+		{postfix: time.RFC3339, valid: true},
+		{postfix: "20060102", valid: true},
+		{postfix: "", valid: false},
+		{postfix: "2006/01/02", valid: false},
+		{postfix: `2006\01\02`, valid: false},
+	}
+
+	for _, tt := range tests {
+		c := DefaultConfig()
+		c.RotatePostfix = tt.postfix
+
+		err := c.Validate()
+		if tt.valid {
+			require.NoError(t, err, "expected postfix %q to be valid, got '%v'", tt.postfix, err)
+		} else {
+			require.ErrorIs(t, err, ErrInvalidConfig, "expected postfix %q to be invalid", tt.postfix)
+		}
+	}
+}
+
+func TestNewConfig(t *testing.T) {
+	afs := &afero.Afero{Fs: afero.NewMemMapFs()}
+
+	c, err := NewConfig(WithFileSystem(afs), WithFileMaxSize(1), WithLogFlushInterval(0))
+	require.NoError(t, err, "expected no error when building config, got '%v'", err)
+	require.Equal(t, uint(1024*1024), c.MaxSize, "unexpected max size")
+
+	c.AsyncQueueSize = -1
+	c.OverflowPolicy = OverflowSpill
+
+	err = c.Validate()
+
+	var configErr *ConfigError
+	require.ErrorAs(t, err, &configErr, "expected a *ConfigError, got '%v'", err)
+	require.Len(t, configErr.Errs, 2, "expected the queue size and the spill writer to be reported")
+
+	c.AsyncQueueSize = 0
+	c.OverflowPolicy = OverflowBlock
+
+	fw, err := New("test.log", WithConfig(c))
+	require.NoError(t, err, "expected no error when creating file writer, got '%v'", err)
+	defer fw.Close()
+
+	require.Nil(t, fw.FlushTicker, "expected periodic flushes to be disabled")
+}
+
+func TestValidateFramedFlags(t *testing.T) {
+	c := DefaultConfig()
+	c.Framed = true
+	c.Flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+
+	err := c.Validate()
+
+	var settingErr *SettingError
+	require.ErrorAs(t, err, &settingErr, "expected a *SettingError, got '%v'", err)
+	require.Equal(t, "Flags", settingErr.Setting, "expected the flags to be reported")
+
+	c.Flags = os.O_RDWR | os.O_CREATE | os.O_APPEND
+
+	err = c.Validate()
+	require.NoError(t, err, "expected a framed log opened for reading to be valid, got '%v'", err)
+}
+
+func TestValidateWatchFile(t *testing.T) {
+	c := DefaultConfig()
+	c.WatchFile = true
+	c.FlushInterval = 0
+
+	err := c.Validate()
+
+	var settingErr *SettingError
+	require.ErrorAs(t, err, &settingErr, "expected a *SettingError, got '%v'", err)
+	require.Equal(t, "WatchFile", settingErr.Setting, "expected the watch to be reported")
+
+	c.FlushInterval = time.Second
+
+	err = c.Validate()
+	require.NoError(t, err, "expected a watched file with a flush interval to be valid, got '%v'", err)
+}
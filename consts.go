@@ -10,6 +10,10 @@ const (
 	// group and others have read and execute permissions only.
 	defaulFileMode = 0755
 
+	// The mode of the directories created for the log file if they
+	// don't exist yet.
+	defaultDirMode = 0755
+
 	// os.O_CREATE creates the file if it doesn't exist, os.O_WRONLY
 	// opens the file for write-only access, and os.O_APPEND ensures
 	// that data is always written at the end of the file.
@@ -17,6 +21,12 @@ const (
 
 	defaultFileDeleteOld = false
 
+	// Backups are kept forever by default; pruning is enabled by
+	// setting any of the retention limits.
+	defaultFileMaxBackups   = 0
+	defaultFileMaxAge       = 0
+	defaultFileMaxTotalSize = 0
+
 	// Defines the timestamp format that is appended to the file name
 	// after rotation. For example, if the original file name was
 	// "test.log", after rotation with the default postfix, it will be
@@ -28,26 +38,43 @@ const (
 	// the file.
 	defaulFileCompress = true
 
+	// The log file is fsynced on rotation and close only, which makes
+	// finished log files and their renames durable without putting
+	// fsync on the write path.
+	defaultSyncMode = SyncOnRotate
+
+	// Rotated files are compressed by a single background worker by
+	// default. Up to defaultCompressQueueSize files may wait for it;
+	// the files rotated while the queue is full are queued later.
+	defaultCompressWorkers   = 1
+	defaultCompressQueueSize = 16
+	defaultWaitCompress      = true
+
+	// CloseContext abandons the compressions still running this long
+	// before its deadline, which leaves time for the workers to stop
+	// and the uncompressed backups to be reported.
+	closeCompressMargin = time.Second
+
 	// The maximum size of the log file in bytes, by the default it
 	// equals to 4_194_304 B or 4 MB.
 	defaulFileMaxSize = 4 * 1024 * 1024
 
-	// The maximum number of log entries that can be buffered before
-	// the logs are flushed.
-	defaulBufMaxBatchSize = 64
+	// The marker that ends a record truncated because it didn't fit
+	// into a log file.
+	defaultTruncateMarker = "...[truncated]\n"
+
+	// The capacity of the log buffer in bytes. The buffer is flushed
+	// when it's full, so it also bounds the size of a single write
+	// to the log file.
+	defaultBufSize = 4096
+
+	// The buffer isn't flushed after a number of log entries by
+	// default, since the size of the entries varies; it is flushed
+	// when it's full or by the flush ticker instead.
+	defaulBufMaxBatchSize = 0
 
 	// The interval at which the log buffer is flushed to disk, helps
 	// to ensure that logs are written periodically even if the batch
 	// size is not reached.
 	defaulBufFlushInterval = 10 * time.Second
 )
-
-const (
-	wFailedToOpenLogFile     = "failed to open log file: %w"
-	wFailedToRenameLogFile   = "failed to rename log file: %w"
-	wFailedToGetFileStats    = "failed to get file stats: %w"
-	wFailedToWriteLogFile    = "failed to write log file: %w"
-	wFailedToCompressLogFile = "failed to compress log file: %w"
-	wFailedToRemoveLogFile   = "failed to remove log file: %w"
-	wFailedToFlushLogBuffer  = "failed to flush log buffer: %w"
-)
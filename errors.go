@@ -0,0 +1,82 @@
+package filewriter
+
+import (
+	"errors"
+	"os"
+)
+
+var (
+	// ErrClosed is the cause of the errors returned by the methods of
+	// a FileWriter called after Close.
+	ErrClosed = errors.New("file writer is closed")
+
+	// ErrRecordTooLarge matches every *RecordTooLargeError with
+	// errors.Is.
+	ErrRecordTooLarge = errors.New("record too large")
+)
+
+// Op is the operation of the FileWriter an *Error was returned by.
+type Op string
+
+const (
+	OpOpen       Op = "open"       // opening or creating the log file
+	OpRecover    Op = "recover"    // truncating the torn frame of a framed log
+	OpStat       Op = "stat"       // getting the stats of the log file
+	OpList       Op = "list"       // listing the backups of the log file
+	OpWrite      Op = "write"      // writing a record
+	OpFlush      Op = "flush"      // flushing the log buffer
+	OpSync       Op = "sync"       // fsyncing the log file or its directory
+	OpRotate     Op = "rotate"     // renaming the log file to its backup
+	OpReopen     Op = "reopen"     // reopening the log file
+	OpCompress   Op = "compress"   // compressing a backup
+	OpDecompress Op = "decompress" // decompressing a backup
+	OpRemove     Op = "remove"     // removing the log file or a backup
+	OpLoad       Op = "load"       // loading a configuration file
+	OpUpdate     Op = "update"     // changing the settings of a running FileWriter
+)
+
+// Error records the operation that failed, the file it failed on and
+// its cause, e.g. the syscall.Errno reported by the filesystem, so
+// that errors.Is(err, syscall.ENOSPC) or errors.Is(err,
+// fs.ErrPermission) tell a full disk from missing permissions.
+type Error struct {
+	Op   Op
+	Path string // empty if the error isn't related to a single file
+	Err  error
+}
+
+// newError returns the *Error of op failing on path. The *os.PathError
+// and *os.LinkError causes are flattened, since Error carries the path
+// itself; their path is used if path is empty.
+func newError(op Op, path string, err error) *Error {
+	switch e := err.(type) {
+	case *os.PathError:
+		if path == "" {
+			path = e.Path
+		}
+		err = e.Err
+
+	case *os.LinkError:
+		if path == "" {
+			path = e.Old
+		}
+		err = e.Err
+
+	case *os.SyscallError:
+		err = e.Err
+	}
+
+	return &Error{Op: op, Path: path, Err: err}
+}
+
+func (e *Error) Error() string {
+	if e.Path == "" {
+		return "failed to " + string(e.Op) + ": " + e.Err.Error()
+	}
+
+	return "failed to " + string(e.Op) + " " + e.Path + ": " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,119 @@
+package filewriter
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+// fullFile fails every write the way a file on a full disk does.
+type fullFile struct {
+	file
+}
+
+func (f *fullFile) Write(p []byte) (int, error) {
+	return 0, &os.PathError{Op: "write", Path: f.Name(), Err: syscall.ENOSPC}
+}
+
+func TestErrorFlushNoSpace(t *testing.T) {
+	fw, err := New("test.log", WithFileSystem(afero.NewMemMapFs()), WithLogFlushInterval(0))
+	require.NoError(t, err, "expected no error when creating file writer, got '%v'", err)
+
+	fw.File = &fullFile{file: fw.File}
+	fw.Wc.wr = fw.File
+
+	_, err = fw.Write([]byte("Hello, world!\n"))
+	require.NoError(t, err, "expected no error when buffering, got '%v'", err)
+
+	err = fw.Flush()
+	require.ErrorIs(t, err, syscall.ENOSPC, "expected the cause to be kept, got '%v'", err)
+
+	var e *Error
+	require.ErrorAs(t, err, &e, "expected an *Error, got '%v'", err)
+	require.Equal(t, OpFlush, e.Op, "unexpected operation")
+	require.Equal(t, "test.log", e.Path, "unexpected path")
+	require.Equal(t, "failed to flush test.log: "+syscall.ENOSPC.Error(), e.Error(), "unexpected message")
+}
+
+func TestErrorOpenPermission(t *testing.T) {
+	fs := afero.NewReadOnlyFs(afero.NewMemMapFs())
+
+	_, err := New("logs/test.log", WithFileSystem(fs))
+	require.ErrorIs(t, err, os.ErrPermission, "expected a permission error, got '%v'", err)
+
+	var e *Error
+	require.ErrorAs(t, err, &e, "expected an *Error, got '%v'", err)
+	require.Equal(t, OpOpen, e.Op, "unexpected operation")
+}
+
+func TestErrorClosed(t *testing.T) {
+	fw, err := New("test.log", WithFileSystem(afero.NewMemMapFs()), WithLogFlushInterval(0))
+	require.NoError(t, err, "expected no error when creating file writer, got '%v'", err)
+
+	err = fw.Close()
+	require.NoError(t, err, "expected no error when closing, got '%v'", err)
+
+	_, err = fw.Write([]byte("Hello, world!\n"))
+	require.ErrorIs(t, err, ErrClosed, "expected a closed error when writing, got '%v'", err)
+
+	err = fw.Rotate()
+	require.ErrorIs(t, err, ErrClosed, "expected a closed error when rotating, got '%v'", err)
+
+	var e *Error
+	require.ErrorAs(t, err, &e, "expected an *Error, got '%v'", err)
+	require.Equal(t, OpRotate, e.Op, "unexpected operation")
+}
+
+func TestErrorRecordTooLarge(t *testing.T) {
+	var err error = &RecordTooLargeError{Size: 2, MaxSize: 1}
+
+	require.True(t, errors.Is(err, ErrRecordTooLarge), "expected the sentinel to match")
+}
+
+// unsyncableFile fails every fsync the way a file on a failing disk
+// does.
+type unsyncableFile struct {
+	file
+}
+
+func (f *unsyncableFile) Sync() error {
+	return &os.PathError{Op: "sync", Path: f.Name(), Err: syscall.EIO}
+}
+
+func TestErrorRotateSyncKeepsFile(t *testing.T) {
+	afs := &afero.Afero{Fs: afero.NewMemMapFs()}
+	payload := []byte("Hello, world!\n")
+
+	fw, err := New("test.log", WithFileSystem(afs), WithLogFlushInterval(0), WithSyncMode(SyncOnRotate))
+	require.NoError(t, err, "expected no error when creating file writer, got '%v'", err)
+
+	file := fw.File
+	fw.File = &unsyncableFile{file: file}
+	fw.Wc.wr = fw.File
+
+	err = fw.Rotate()
+	require.ErrorIs(t, err, syscall.EIO, "expected the failed fsync to be returned, got '%v'", err)
+
+	_, err = fw.Write(payload)
+	require.NoError(t, err, "expected no error when writing after a failed rotation, got '%v'", err)
+
+	err = fw.Flush()
+	require.NoError(t, err, "expected no error when flushing after a failed rotation, got '%v'", err)
+
+	content, err := afs.ReadFile("test.log")
+	require.NoError(t, err, "expected no error when reading file, got '%v'", err)
+	require.Equal(t, payload, content, "expected the record to be written to the current file")
+
+	fw.File = file
+	fw.Wc.wr = file
+
+	err = fw.Close()
+	require.NoError(t, err, "expected no error when closing, got '%v'", err)
+
+	err = fw.Sync()
+	require.ErrorIs(t, err, ErrClosed, "expected a closed error when syncing, got '%v'", err)
+}
@@ -0,0 +1,115 @@
+package filewriter
+
+import (
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+// PublishExpvar publishes the Stats of the FileWriter as an expvar
+// variable with the given name, which makes them available at
+// /debug/vars. Like expvar.Publish, it panics if the name is
+// already in use.
+func (fw *FileWriter) PublishExpvar(name string) {
+	expvar.Publish(name, expvar.Func(func() any {
+		return fw.Stats()
+	}))
+}
+
+// MetricsHandler returns an http.Handler serving the Stats of the
+// FileWriter in the Prometheus text exposition format. Every metric
+// name starts with namespace followed by an underscore; if namespace
+// is empty, "filewriter" is used.
+func (fw *FileWriter) MetricsHandler(namespace string) http.Handler {
+	if namespace == "" {
+		namespace = "filewriter"
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		WritePrometheus(w, namespace, fw.Stats())
+	})
+}
+
+// WritePrometheus writes the stats to w in the Prometheus text
+// exposition format, prefixing every metric name with namespace.
+func WritePrometheus(w io.Writer, namespace string, s Stats) error {
+	pw := &promWriter{w: w, namespace: namespace}
+
+	pw.metric("bytes_written_total", "counter", "Bytes flushed to log files.", s.BytesWritten)
+	pw.metric("records_written_total", "counter", "Records accepted by Write.", s.RecordsWritten)
+	pw.metric("flushes_total", "counter", "Flushes of a non-empty buffer.", s.Flushes)
+	pw.histogram("flush_duration_seconds", "Latency of buffer flushes.", s.FlushLatency)
+	pw.metric("rotations_total", "counter", "Rotations of the log file.", s.Rotations)
+	pw.metric("compressions_total", "counter", "Compressed rotated files.", s.Compressions)
+	pw.metric("compress_seconds_total", "counter", "Time spent compressing.", s.CompressTime.Seconds())
+	pw.metric("compress_input_bytes_total", "counter", "Size of the files before compression.", s.CompressedBytes)
+	pw.metric("compress_output_bytes_total", "counter", "Size of the files after compression.", s.CompressedOutput)
+	pw.metric("compress_ratio", "gauge", "Overall compression ratio.", s.CompressRatio)
+	pw.metric("dropped_records_total", "counter", "Records dropped by the asynchronous writer.", s.Dropped)
+
+	kinds := make([]string, 0, len(s.Errors))
+	for kind := range s.Errors {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	pw.header("errors_total", "counter", "Errors by the kind of failed operation.")
+	for _, kind := range kinds {
+		pw.sample("errors_total", `kind="`+kind+`"`, s.Errors[kind])
+	}
+
+	return pw.err
+}
+
+// promWriter writes metrics in the Prometheus text exposition
+// format, remembering the first write error.
+type promWriter struct {
+	w         io.Writer
+	namespace string
+	err       error
+}
+
+func (pw *promWriter) printf(format string, args ...any) {
+	if pw.err != nil {
+		return
+	}
+
+	_, pw.err = fmt.Fprintf(pw.w, format, args...)
+}
+
+func (pw *promWriter) header(name, typ, help string) {
+	pw.printf("# HELP %s_%s %s\n", pw.namespace, name, help)
+	pw.printf("# TYPE %s_%s %s\n", pw.namespace, name, typ)
+}
+
+func (pw *promWriter) sample(name, labels string, value any) {
+	if labels != "" {
+		labels = "{" + labels + "}"
+	}
+
+	pw.printf("%s_%s%s %v\n", pw.namespace, name, labels, value)
+}
+
+func (pw *promWriter) metric(name, typ, help string, value any) {
+	pw.header(name, typ, help)
+	pw.sample(name, "", value)
+}
+
+func (pw *promWriter) histogram(name, help string, h Histogram) {
+	pw.header(name, "histogram", help)
+
+	var cumulative uint64
+	for i, bound := range h.Buckets {
+		cumulative += h.Counts[i]
+		le := strconv.FormatFloat(bound.Seconds(), 'g', -1, 64)
+		pw.sample(name+"_bucket", `le="`+le+`"`, cumulative)
+	}
+
+	pw.sample(name+"_bucket", `le="+Inf"`, h.Count)
+	pw.sample(name+"_sum", "", h.Sum.Seconds())
+	pw.sample(name+"_count", "", h.Count)
+}
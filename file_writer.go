@@ -1,9 +1,8 @@
 package filewriter
 
 import (
-	"bufio"
+	"context"
 	"errors"
-	"fmt"
 	"io"
 	"os"
 	"sync"
@@ -20,6 +19,7 @@ type file interface {
 	Write(p []byte) (int, error)
 	Stat() (os.FileInfo, error)
 	Seek(offset int64, whence int) (int64, error)
+	Sync() error
 	Close() error
 }
 
@@ -28,101 +28,158 @@ var _ io.WriteCloser = (*FileWriter)(nil)
 type FileWriter struct {
 	mu sync.Mutex
 
-	Mode          os.FileMode
-	Flags         int
-	File          file
-	DeleteOld     bool   // indicates whether the old log file should be removed after rotation
-	RotatePostfix string // the postfix added to the file name during log rotation
-	Compress      bool   // indicates whether the log file should be compressed
-	MaxSize       uint   // the maximum allowed size of the log file (in bytes)
-	Size          uint   // the current size of the log file + buffer size (in bytes)
+	Config
 
-	Buf          *bufio.Writer
-	Wc           *writeCounter
-	MaxBatchSize int // the maximum number of log entries to accumulate before flushing
-	BatchSize    int // the current number of log entries in the buffer
+	File file
+	Size uint // the current size of the log file + buffer size (in bytes)
+
+	// the time the current log file was started, used by time-based
+	// rotation policies
+	OpenedAt time.Time
+
+	Buf       *Buffer
+	Wc        *writeCounter
+	BatchSize int // the current number of log entries in the buffer
 
 	// the time.Ticker that triggers periodic flushes of the buffer
+	// every FlushInterval
 	FlushTicker *time.Ticker
-	// the function to handle errors that occur during flushing
-	ErrorHandler func(fw *FileWriter, err error)
-	Done         chan struct{}
+	Done        chan struct{}
+	stopTicker  chan struct{} // stops the goroutine of the FlushTicker
+
+	unsynced uint      // the number of bytes flushed since the last fsync
+	lastSync time.Time // the time of the last fsync
+
+	frame []byte // the scratch buffer the frame of a record is built in
+
+	// the rotated files waiting for room in the queue of the
+	// background compressor
+	pendingCompress []compressJob
+	// indicates whether the rotated files are kept pending, since
+	// CloseContext is past its compression margin
+	holdCompress bool
+
+	bufferedAt   time.Time   // the time the oldest buffered data was written
+	latencyTimer *time.Timer // flushes the buffer once FlushLatency passes
 
-	closeOnce sync.Once
+	metrics metrics
+	hooks   hookQueue
+	subs    subscribers
+
+	closeOnce  sync.Once
+	pruneMu    sync.Mutex
+	compressor *compressor
+	async      *asyncWriter
 }
 
 func (fw *FileWriter) runTicker() {
-	if fw.FlushTicker == nil {
+	if fw.FlushInterval <= 0 {
+		fw.FlushTicker = nil
 		return
 	}
 
+	ticker := time.NewTicker(fw.FlushInterval)
+	stop := make(chan struct{})
+	done := fw.Done
+
+	fw.FlushTicker, fw.stopTicker = ticker, stop
+
 	go func() {
 		for {
 			select {
-			case <-fw.Done:
+			case <-done:
 				return
-			case <-fw.FlushTicker.C:
-				fw.mu.Lock()
+			case <-stop:
+				return
+			case <-ticker.C:
+				fw.tick()
+			}
+		}
+	}()
+}
 
-				err := func() error {
-					bufSize := uint(fw.Buf.Buffered())
-					afterWriteSize := fw.Size + bufSize
+// restartTicker replaces the FlushTicker with one ticking every
+// FlushInterval. It must be called with fw.mu held.
+func (fw *FileWriter) restartTicker() {
+	if fw.FlushTicker != nil {
+		fw.FlushTicker.Stop()
+		close(fw.stopTicker)
+	}
 
-					var err error
-					if afterWriteSize >= fw.MaxSize {
-						err = fw.rotateFile()
-					}
+	fw.runTicker()
+}
 
-					if err == nil {
-						fw.BatchSize = 0
-						err = fw.flushBuf()
-					}
+// tick performs the periodic work of the FlushTicker.
+func (fw *FileWriter) tick() {
+	defer fw.runHooks()
 
-					return err
-				}()
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
 
-				if err != nil {
-					fw.ErrorHandler(fw, err)
-				}
+	// The ticker may fire while Close is waiting for the lock.
+	if fw.File == nil {
+		return
+	}
+
+	fw.queuePendingCompress()
 
-				fw.mu.Unlock()
+	err := func() error {
+		if fw.WatchFile {
+			err := fw.checkFile()
+			if err != nil {
+				return err
 			}
 		}
+
+		if fw.shouldRotate(0) {
+			return fw.rotate()
+		}
+
+		fw.BatchSize = 0
+		return fw.flushBuf()
 	}()
-}
 
-func New(file string, opts ...Option) (*FileWriter, error) {
-	fw := &FileWriter{
-		Mode:          defaulFileMode,
-		Flags:         defaulFileFlags,
-		DeleteOld:     defaultFileDeleteOld,
-		RotatePostfix: defaultFileRotatePostfix,
-		Compress:      defaulFileCompress,
-		MaxSize:       defaulFileMaxSize,
+	if err != nil {
+		fw.ErrorHandler(fw, err)
+	}
+}
 
-		MaxBatchSize: defaulBufMaxBatchSize,
-		FlushTicker:  time.NewTicker(defaulBufFlushInterval),
-		ErrorHandler: func(fw *FileWriter, err error) {},
-		closeOnce:    sync.Once{},
+// newFileWriter returns a FileWriter holding the default settings
+// with the options applied, without opening the log file.
+func newFileWriter(opts ...Option) (*FileWriter, error) {
+	c, err := NewConfig(opts...)
+	if err != nil {
+		return nil, err
 	}
 
-	for _, opt := range opts {
-		opt(fw)
+	return &FileWriter{Config: c}, nil
+}
+
+// New opens the log file with the given name and starts writing to
+// it. The options are applied to the DefaultConfig; if any of them or
+// the resulting Config is invalid, a *ConfigError listing every
+// problem is returned and no file is opened.
+func New(file string, opts ...Option) (*FileWriter, error) {
+	fw, err := newFileWriter(opts...)
+	if err != nil {
+		return nil, err
 	}
 
-	err := fw.openFile(file, fw.Mode)
+	err = fw.openFile(file, fw.Mode)
 	if err != nil {
 		return nil, err
 	}
 
 	fw.mu = sync.Mutex{}
 	fw.Wc = &writeCounter{wr: fw.File}
-	fw.Buf = bufio.NewWriter(fw.Wc)
+	fw.Buf = NewBuffer(fw.Wc, fw.BufSize)
 
 	fw.BatchSize = 0
 	fw.Done = make(chan struct{})
 
 	fw.runTicker()
+	fw.runCompressor()
+	fw.runAsync()
 
 	return fw, nil
 }
@@ -144,104 +201,362 @@ func (fw *FileWriter) Open(file string, mode int) error {
 	}
 
 	fw.Mode = m
-	fw.setBufWriter(fw.File)
+
+	if fw.Wc == nil {
+		fw.Wc = &writeCounter{}
+	}
+	fw.Wc.wr = fw.File
+
+	if fw.Buf == nil {
+		fw.Buf = NewBuffer(fw.Wc, fw.BufSize)
+	}
+	fw.Buf.SetWriter(fw.Wc)
 	fw.Done = make(chan struct{})
 	fw.closeOnce = sync.Once{}
 
 	fw.runTicker()
+	fw.runCompressor()
+	fw.runAsync()
 
 	return nil
 }
 
-// Write writes the provided data to the log file while ensuring
-// that the total size of the file, the buffered data, and the
-// new data does not exceed the maximum allowed size. If the new
-// data would cause the size to surpass this limit, the log file
-// is rotated and any buffered data is flushed before proceeding.
+// Write writes the provided data to the log file. Before the data
+// is buffered, the RotatePolicy is consulted with the total size
+// of the file, the buffered data, and the new data; if it asks for
+// rotation, any buffered data is flushed and the log file is
+// rotated before proceeding. Every call to Write lands entirely in
+// one log file; a record larger than MaxSize is handled according
+// to the OversizePolicy.
 // After writing, if the number of batched entries reaches the
 // predefined threshold, the buffer is flushed.
+//
+// In asynchronous mode, Write only copies p into the queue and the
+// steps above are performed later by the writer goroutine.
 func (fw *FileWriter) Write(p []byte) (int, error) {
+	if fw.async != nil {
+		return fw.async.enqueue(fw, p)
+	}
+
 	if fw.File == nil {
-		return 0, fmt.Errorf(wFailedToWriteLogFile, os.ErrClosed)
+		return 0, newError(OpWrite, "", ErrClosed)
 	}
 
+	defer fw.runHooks()
+
 	fw.mu.Lock()
 	defer fw.mu.Unlock()
 
-	pSize := uint(len(p))
-	bufSize := uint(fw.Buf.Buffered())
-	afterWriteSize := fw.Size + pSize + bufSize
+	return fw.writeEntry(p, false)
+}
 
-	var err error
-	if afterWriteSize >= fw.MaxSize {
-		err = fw.rotateFile()
+// WriteUrgent writes the provided data like Write and flushes the
+// buffer right after it, so that the record reaches the log file
+// even if the process crashes right away. In asynchronous mode, the
+// queued records are written first.
+func (fw *FileWriter) WriteUrgent(p []byte) (int, error) {
+	if fw.async != nil {
+		n, err := fw.async.enqueue(fw, p)
 		if err != nil {
-			return 0, err
+			return n, err
 		}
 
+		return n, fw.Flush()
+	}
+
+	if fw.File == nil {
+		return 0, newError(OpWrite, "", ErrClosed)
+	}
+
+	defer fw.runHooks()
+
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	return fw.writeEntry(p, true)
+}
+
+// writeEntry writes the record and flushes the buffer right after
+// it if the record is urgent, either because it was written with
+// WriteUrgent or because the Urgent predicate says so. It must be
+// called with fw.mu held.
+func (fw *FileWriter) writeEntry(p []byte, urgent bool) (int, error) {
+	n, err := fw.writeRecord(p)
+	if err != nil {
+		return n, err
+	}
+
+	if urgent || (fw.Urgent != nil && fw.Urgent(p)) {
+		fw.BatchSize = 0
 		err = fw.flushBuf()
+	}
+
+	return n, err
+}
+
+// writeRecord writes p according to the OversizePolicy, framing it
+// first if framing is enabled. It must be called with fw.mu held.
+func (fw *FileWriter) writeRecord(p []byte) (int, error) {
+	var overhead uint
+	if fw.Framed {
+		overhead = frameHeaderSize
+	}
+
+	size := uint(len(p)) + overhead
+	if size <= fw.MaxSize {
+		n, err := fw.write(fw.encode(p))
+		return fw.written(p, n, err)
+	}
+
+	switch fw.OversizePolicy {
+	case OversizeReject:
+		return 0, &RecordTooLargeError{Size: size, MaxSize: fw.MaxSize}
+
+	case OversizeTruncate:
+		var max uint
+		if fw.MaxSize > overhead {
+			max = fw.MaxSize - overhead
+		}
+
+		_, err := fw.write(fw.encode(truncateRecord(p, max, fw.TruncateMarker)))
 		if err != nil {
 			return 0, err
 		}
 
-		fw.BatchSize = 0
+		return len(p), nil
+
+	default:
+		n, err := fw.writeIsolated(fw.encode(p))
+		return fw.written(p, n, err)
+	}
+}
+
+// encode returns the frame of p if framing is enabled and p itself
+// otherwise. The frame is only valid until the next call.
+func (fw *FileWriter) encode(p []byte) []byte {
+	if !fw.Framed {
+		return p
+	}
+
+	fw.frame = appendFrame(fw.frame[:0], p)
+
+	return fw.frame
+}
+
+// written translates the number of bytes of the encoded record
+// written into the number of bytes of p, which is all of them once
+// a frame was written successfully.
+func (fw *FileWriter) written(p []byte, n int, err error) (int, error) {
+	if !fw.Framed {
+		return n, err
 	}
 
+	if err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+func (fw *FileWriter) write(p []byte) (int, error) {
+	if fw.shouldRotate(uint(len(p))) {
+		err := fw.rotate()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	empty := fw.Buf.Buffered() == 0
+
 	n, err := fw.Buf.Write(p)
 	if err != nil {
-		err = errors.Unwrap(err)
-		return n, fmt.Errorf(wFailedToWriteLogFile, err)
+		return n, fw.metrics.countError(errorKindWrite, newError(OpWrite, fw.File.Name(), err))
 	}
 
+	fw.metrics.recordsWritten.Add(1)
 	fw.BatchSize++
-	if fw.BatchSize >= fw.MaxBatchSize {
+
+	// The buffer overflowed if it wrote some of the data on its own,
+	// in which case the data left in it is new.
+	overflowed := fw.Wc.flushedBytes > 0
+	if empty || overflowed {
+		fw.markBuffered()
+	}
+
+	if fw.shouldFlush() {
 		fw.BatchSize = 0
-		// Flush the buffer without rotating, because after the
-		// postWriteSize calculation we assume that there will be enough
-		// space after rotation, but in some cases (e.g., when an
-		// excessively large number of bytes is passed), this assumption
-		// might not hold; it is the user's responsibility to ensure that
-		// the input size remains within acceptable limits.
-		err = fw.flushBuf()
+		return n, fw.flushBuf()
 	}
 
-	return n, err
+	if overflowed {
+		return n, fw.syncAfterFlush(fw.accountFlushed())
+	}
+
+	return n, nil
 }
 
-// Close terminates the FileWriter by stopping the periodic flush
-// ticker, closing the done channel, and then ensuring that any
-// buffered log data is properly handled before the file is closed.
-// It calculates the total size as the sum of the current file size
-// and the number of bytes buffered. If this total exceeds the
-// maximum allowed size, the log file is rotated. If no error ccurs
-// during rotation, the remaining buffered data is flushed to the
-// file.
-func (fw *FileWriter) Close() error {
+// Rotate forces rotation of the log file regardless of the
+// RotatePolicy. The buffered data is flushed to the current file
+// first, then the file is rotated and writing continues on the new
+// one. It is safe to call Rotate concurrently with Write.
+func (fw *FileWriter) Rotate() error {
+	defer fw.runHooks()
+
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	if fw.File == nil {
+		return newError(OpRotate, "", ErrClosed)
+	}
+
+	return fw.rotate()
+}
+
+// Flush writes the buffered data to the log file. In asynchronous
+// mode, the queued records are written first. Unlike Sync, it
+// doesn't fsync the file, unless the SyncMode asks for it.
+func (fw *FileWriter) Flush() error {
+	return fw.FlushContext(context.Background())
+}
+
+// FlushContext is like Flush, but stops writing the queued records
+// of the asynchronous mode once ctx ends; the buffered data is
+// flushed regardless. If ctx ended before everything was written, an
+// *IncompleteError describing the unwritten records is returned, and
+// the records stay queued.
+func (fw *FileWriter) FlushContext(ctx context.Context) error {
+	incomplete := &IncompleteError{}
+
+	defer fw.runHooks()
+
+	if !fw.lockContext(ctx) {
+		incomplete.Busy = true
+		return incomplete.result(ctx)
+	}
+	defer fw.mu.Unlock()
+
+	if fw.File == nil {
+		return newError(OpFlush, "", ErrClosed)
+	}
+
+	if fw.async != nil {
+		fw.async.drainLocked(ctx, fw)
+		incomplete.Records = fw.async.queue.len()
+	}
+
+	fw.BatchSize = 0
+	err := fw.flushBuf()
+
+	return errors.Join(err, incomplete.result(ctx))
+}
+
+// Reopen flushes the buffered data, closes the log file and opens
+// the file at the same path again. It is meant to be called after
+// the log file was moved by an external tool such as logrotate, and
+// is safe to call concurrently with Write.
+func (fw *FileWriter) Reopen() error {
 	fw.mu.Lock()
 	defer fw.mu.Unlock()
 
+	if fw.File == nil {
+		return newError(OpReopen, "", ErrClosed)
+	}
+
+	err := fw.flushBuf()
+	if err != nil {
+		return err
+	}
+
+	fw.BatchSize = 0
+
+	return fw.reopenFile()
+}
+
+// Close terminates the FileWriter by writing the records queued in
+// asynchronous mode, stopping the periodic flush ticker, closing the
+// done channel, and then ensuring that any buffered log data is
+// properly handled before the file is closed. The buffered data is
+// flushed first; then, if the RotatePolicy asks for rotation given
+// the current file size, the log file is rotated. If no error occurs
+// during rotation, the file is, unless SyncMode is SyncNever, fsynced
+// and closed. Finally, the background compressor is stopped; if
+// WaitCompress is set, Close waits for the queued compressions.
+func (fw *FileWriter) Close() error {
+	return fw.CloseContext(context.Background())
+}
+
+// CloseContext is like Close, but bounds the time it takes by ctx,
+// e.g. the grace period before a container is killed. Persisting the
+// buffered data comes first and is always done once the lock is
+// taken. Once ctx ends, the records still queued in asynchronous
+// mode are dropped and the rotation and the fsync are skipped. The
+// compressions are abandoned earlier, a second before the deadline
+// of ctx, leaving the backups uncompressed. Everything left
+// unfinished is described by the returned *IncompleteError.
+func (fw *FileWriter) CloseContext(ctx context.Context) error {
+	incomplete := &IncompleteError{}
+
+	// The queue is drained before fw.mu is taken, since the writer
+	// goroutine needs the lock to write the records.
+	if fw.async != nil {
+		incomplete.Records = fw.async.stopContext(ctx)
+	}
+
+	defer fw.runHooks()
+
+	if !fw.lockContext(ctx) {
+		incomplete.Busy = true
+		return incomplete.result(ctx)
+	}
+	defer fw.mu.Unlock()
+
+	compressCtx, cancel := withMargin(ctx, closeCompressMargin)
+	defer cancel()
+
 	var err error
 	closeFn := func() {
-		fw.FlushTicker.Stop()
+		if fw.FlushTicker != nil {
+			fw.FlushTicker.Stop()
+		}
+		if fw.latencyTimer != nil {
+			fw.latencyTimer.Stop()
+		}
 		close(fw.Done)
 
-		bufSize := uint(fw.Buf.Buffered())
-		afterWriteSize := fw.Size + bufSize
+		fw.holdCompress = compressCtx.Err() != nil
 
-		if afterWriteSize >= fw.MaxSize {
-			err = fw.rotateFile()
-			if err != nil {
-				return
+		err = fw.flushBuf()
+		if err == nil && fw.shouldRotate(0) {
+			if ctx.Err() != nil {
+				incomplete.Rotation = true
+			} else {
+				err = fw.rotate()
+				if err != nil {
+					return
+				}
 			}
 		}
 
-		err = fw.flushBuf()
+		if err == nil && fw.SyncMode != SyncNever {
+			if ctx.Err() != nil {
+				incomplete.Unsynced = true
+			} else {
+				err = fw.syncFile()
+			}
+		}
 
 		fw.File.Close()
 		fw.File = nil
+
+		if fw.compressor != nil {
+			incomplete.Uncompressed = fw.compressor.stopContext(compressCtx, fw.WaitCompress, fw.pendingCompress)
+			fw.compressor, fw.pendingCompress = nil, nil
+		}
 	}
 
 	fw.closeOnce.Do(closeFn)
 
-	return err
+	// compressCtx ends no later than ctx, so backups abandoned before
+	// the deadline are reported too.
+	return errors.Join(err, incomplete.result(compressCtx))
 }
@@ -1,12 +1,13 @@
 package filewriter
 
 import (
-	"bufio"
 	"bytes"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -27,10 +28,9 @@ func TestFileWriterSuite(t *testing.T) {
 	wc := &writeCounter{wr: &writer}
 
 	fw := &FileWriter{
-		Mode:  defaulFileMode,
-		Flags: defaulFileFlags,
-		Wc:    wc,
-		Buf:   bufio.NewWriter(wc),
+		Config: Config{Mode: defaulFileMode, Flags: defaulFileFlags},
+		Wc:     wc,
+		Buf:    NewBuffer(wc, 0),
 	}
 
 	filePayload := []byte("Hello, world!\n")
@@ -42,13 +42,7 @@ func TestFileWriterSuite(t *testing.T) {
 		fw:          fw,
 	}
 
-	openFileFn = func(name string, flag int, mode os.FileMode) (file, error) {
-		return tf.afs.OpenFile(name, flag, mode)
-	}
-
-	renameFileFn = func(oldpath, newpath string) error {
-		return tf.afs.Rename(oldpath, newpath)
-	}
+	fw.Fs = tf.afs
 
 	suite.Run(t, tf)
 }
@@ -56,7 +50,7 @@ func TestFileWriterSuite(t *testing.T) {
 func (tf *testFileWriter) SetupTest() {
 	var writer bytes.Buffer
 	tf.fw.Wc = &writeCounter{wr: &writer}
-	tf.fw.Buf = bufio.NewWriter(tf.fw.Wc)
+	tf.fw.Buf = NewBuffer(tf.fw.Wc, 0)
 }
 
 func (tf *testFileWriter) TearDownSuite() {
@@ -86,3 +80,39 @@ func (tf *testFileWriter) TestOpen() {
 func (tf *testFileWriter) TestWrite() {}
 
 func (tf *testFileWriter) TestClose() {}
+
+func TestRotate(t *testing.T) {
+	afs := &afero.Afero{Fs: afero.NewMemMapFs()}
+	payload := []byte("Hello, world!\n")
+
+	fw, err := New(
+		"test.log",
+		WithFileSystem(afs),
+		WithFileCompress(false),
+		WithLogFlushInterval(0),
+	)
+	require.NoError(t, err, "expected no error when creating file writer, got '%v'", err)
+	defer fw.Close()
+
+	now := time.Now()
+	currentTime = func() time.Time { return now }
+	t.Cleanup(func() { currentTime = time.Now })
+
+	_, err = fw.Write(payload)
+	require.NoError(t, err, "expected no error when writing, got '%v'", err)
+
+	err = fw.Rotate()
+	require.NoError(t, err, "expected no error when rotating, got '%v'", err)
+
+	backupName := "test.log." + now.Format(fw.RotatePostfix)
+	content, err := afs.ReadFile(backupName)
+	require.NoError(t, err, "expected no error when reading backup, got '%v'", err)
+
+	require.Equal(
+		t, payload, content,
+		"expected backup to hold '%v', got '%v'",
+		string(payload), string(content),
+	)
+
+	require.Zerof(t, fw.Size, "expected new file to be empty, got '%v'", fw.Size)
+}
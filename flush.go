@@ -0,0 +1,71 @@
+package filewriter
+
+import "time"
+
+// shouldFlush reports whether the buffer must be flushed after a
+// write: once MaxBatchSize entries or FlushBytes bytes are buffered,
+// or the oldest buffered data has waited for FlushLatency.
+func (fw *FileWriter) shouldFlush() bool {
+	buffered := uint(fw.Buf.Buffered())
+	if buffered == 0 {
+		return false
+	}
+
+	switch {
+	case fw.MaxBatchSize > 0 && fw.BatchSize >= fw.MaxBatchSize:
+	case fw.FlushBytes > 0 && buffered >= fw.FlushBytes:
+	case fw.FlushLatency > 0 && time.Since(fw.bufferedAt) >= fw.FlushLatency:
+	default:
+		return false
+	}
+
+	return true
+}
+
+// markBuffered records the time data started waiting in the buffer
+// and, if FlushLatency is set, arms the timer that flushes it in
+// case no further write does.
+func (fw *FileWriter) markBuffered() {
+	if fw.Buf.Buffered() == 0 {
+		return
+	}
+
+	fw.bufferedAt = time.Now()
+
+	if fw.FlushLatency <= 0 {
+		return
+	}
+
+	if fw.latencyTimer == nil {
+		fw.latencyTimer = time.AfterFunc(fw.FlushLatency, fw.flushLate)
+		return
+	}
+
+	fw.latencyTimer.Reset(fw.FlushLatency)
+}
+
+// flushLate flushes the buffer once the oldest buffered data has
+// waited for FlushLatency. It is run by the latency timer; if the
+// buffer was flushed and refilled since the timer was armed, it is
+// rearmed for the data buffered now.
+func (fw *FileWriter) flushLate() {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	if fw.File == nil || fw.Buf.Buffered() == 0 {
+		return
+	}
+
+	wait := fw.FlushLatency - time.Since(fw.bufferedAt)
+	if wait > 0 {
+		fw.latencyTimer.Reset(wait)
+		return
+	}
+
+	fw.BatchSize = 0
+
+	err := fw.flushBuf()
+	if err != nil {
+		fw.ErrorHandler(fw, err)
+	}
+}
@@ -0,0 +1,62 @@
+package filewriter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlushBytes(t *testing.T) {
+	afs := &afero.Afero{Fs: afero.NewMemMapFs()}
+
+	fw, err := New("test.log", WithFileSystem(afs), WithLogFlushInterval(0), WithFlushBytes(10))
+	require.NoError(t, err, "expected no error when creating file writer, got '%v'", err)
+	defer fw.Close()
+
+	_, err = fw.Write([]byte("12345\n"))
+	require.NoError(t, err, "expected no error when writing, got '%v'", err)
+	require.Equal(t, 6, fw.Buf.Buffered(), "expected record to be buffered")
+
+	_, err = fw.Write([]byte("67890\n"))
+	require.NoError(t, err, "expected no error when writing, got '%v'", err)
+	require.Zero(t, fw.Buf.Buffered(), "expected buffer to be flushed")
+	require.Equal(t, uint(12), fw.Size, "unexpected file size")
+}
+
+func TestFlushLatency(t *testing.T) {
+	afs := &afero.Afero{Fs: afero.NewMemMapFs()}
+
+	fw, err := New("test.log", WithFileSystem(afs), WithLogFlushInterval(0), WithFlushLatency(10*time.Millisecond))
+	require.NoError(t, err, "expected no error when creating file writer, got '%v'", err)
+	defer fw.Close()
+
+	_, err = fw.Write([]byte("Hello, world!\n"))
+	require.NoError(t, err, "expected no error when writing, got '%v'", err)
+
+	require.Eventually(t, func() bool {
+		data, err := afs.ReadFile("test.log")
+		return err == nil && string(data) == "Hello, world!\n"
+	}, time.Second, 5*time.Millisecond, "expected buffer to be flushed once the latency passed")
+}
+
+func TestBufferOverflowSize(t *testing.T) {
+	fw, err := New(
+		"test.log",
+		WithFileSystem(afero.NewMemMapFs()),
+		WithLogFlushInterval(0),
+		WithBufferSize(16),
+	)
+	require.NoError(t, err, "expected no error when creating file writer, got '%v'", err)
+	defer fw.Close()
+
+	for range 3 {
+		_, err = fw.Write([]byte("0123456789\n"))
+		require.NoError(t, err, "expected no error when writing, got '%v'", err)
+	}
+
+	// The data the buffer wrote on its own when the buffer overflowed is
+	// already accounted for.
+	require.Equal(t, uint(33), fw.Size+uint(fw.Buf.Buffered()), "unexpected file size")
+}
@@ -0,0 +1,253 @@
+package filewriter
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// A frame consists of a header followed by the record. The header
+// holds the length of the record, its bitwise complement, which
+// lets a reader tell a header from arbitrary data cheaply when it
+// resynchronizes after corruption, and the CRC-32C of the record.
+// All the fields are little-endian.
+const (
+	frameHeaderSize = 12
+
+	// The maximum length of a record a FrameReader accepts by
+	// default, larger lengths are considered corrupt.
+	defaultMaxFrameSize = 1 << 30
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// appendFrame appends the frame of the record p to dst.
+func appendFrame(dst, p []byte) []byte {
+	n := uint32(len(p))
+
+	dst = binary.LittleEndian.AppendUint32(dst, n)
+	dst = binary.LittleEndian.AppendUint32(dst, ^n)
+	dst = binary.LittleEndian.AppendUint32(dst, crc32.Checksum(p, castagnoli))
+
+	return append(dst, p...)
+}
+
+// parseFrameHeader returns the length and the checksum of the record
+// from the frame header, or false if hdr isn't a valid header.
+func parseFrameHeader(hdr []byte, maxSize uint32) (uint32, uint32, bool) {
+	n := binary.LittleEndian.Uint32(hdr[0:4])
+	if binary.LittleEndian.Uint32(hdr[4:8]) != ^n || n > maxSize {
+		return 0, 0, false
+	}
+
+	return n, binary.LittleEndian.Uint32(hdr[8:12]), true
+}
+
+// CorruptFrameError is returned by FrameReader.Next for data that
+// isn't a valid frame, such as a frame torn by a crash or damaged on
+// the disk. The reader has already skipped the data, so the next
+// call of Next continues with the following frame.
+type CorruptFrameError struct {
+	Offset  int64 // the offset of the corrupt data in the stream
+	Skipped int64 // the number of bytes skipped
+}
+
+func (e *CorruptFrameError) Error() string {
+	return fmt.Sprintf("corrupt frame at offset %d, skipped %d bytes", e.Offset, e.Skipped)
+}
+
+// FrameReader reads the records of a log written with framing
+// enabled. When it meets corrupt data, it scans forward to the next
+// valid frame and reports the skipped bytes with a
+// *CorruptFrameError.
+type FrameReader struct {
+	// MaxFrameSize is the maximum length of a record, larger lengths
+	// are considered corrupt.
+	MaxFrameSize uint32
+
+	r   io.Reader
+	buf []byte
+	pos int
+	eof bool
+
+	offset int64 // the offset of buf[pos] in the stream
+}
+
+func NewFrameReader(r io.Reader) *FrameReader {
+	return &FrameReader{MaxFrameSize: defaultMaxFrameSize, r: r}
+}
+
+// Offset returns the number of bytes consumed from the stream, which
+// after a successful call of Next is the offset of the end of the
+// returned frame.
+func (fr *FrameReader) Offset() int64 {
+	return fr.offset
+}
+
+// fill reads from the stream until at least n bytes are buffered or
+// the stream ends, and reports whether n bytes are available.
+func (fr *FrameReader) fill(n int) (bool, error) {
+	for len(fr.buf)-fr.pos < n && !fr.eof {
+		if fr.pos > 0 {
+			fr.buf = append(fr.buf[:0], fr.buf[fr.pos:]...)
+			fr.pos = 0
+		}
+
+		if cap(fr.buf)-len(fr.buf) < n {
+			grown := make([]byte, len(fr.buf), max(2*cap(fr.buf), len(fr.buf)+n, 4096))
+			copy(grown, fr.buf)
+			fr.buf = grown
+		}
+
+		m, err := fr.r.Read(fr.buf[len(fr.buf):cap(fr.buf)])
+		fr.buf = fr.buf[:len(fr.buf)+m]
+
+		if errors.Is(err, io.EOF) {
+			fr.eof = true
+			break
+		}
+
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return len(fr.buf)-fr.pos >= n, nil
+}
+
+func (fr *FrameReader) skip(n int) {
+	fr.pos += n
+	fr.offset += int64(n)
+}
+
+// Next returns the next record. It returns io.EOF at the end of the
+// stream and a *CorruptFrameError after skipping corrupt data. The
+// record is only valid until the next call of Next.
+func (fr *FrameReader) Next() ([]byte, error) {
+	start := fr.offset
+
+	for {
+		ok, err := fr.fill(frameHeaderSize)
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			// Too few bytes are left for a frame, which happens when
+			// the last frame was torn.
+			rest := len(fr.buf) - fr.pos
+			fr.skip(rest)
+
+			if fr.offset > start {
+				return nil, &CorruptFrameError{Offset: start, Skipped: fr.offset - start}
+			}
+
+			return nil, io.EOF
+		}
+
+		hdr := fr.buf[fr.pos : fr.pos+frameHeaderSize]
+
+		n, sum, ok := parseFrameHeader(hdr, fr.MaxFrameSize)
+		if ok {
+			ok, err = fr.fill(frameHeaderSize + int(n))
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if ok {
+			record := fr.buf[fr.pos+frameHeaderSize : fr.pos+frameHeaderSize+int(n)]
+			ok = crc32.Checksum(record, castagnoli) == sum
+		}
+
+		if !ok {
+			fr.skip(1)
+			continue
+		}
+
+		if fr.offset > start {
+			// The corrupt data is reported first; the frame is
+			// returned by the next call.
+			return nil, &CorruptFrameError{Offset: start, Skipped: fr.offset - start}
+		}
+
+		record := fr.buf[fr.pos+frameHeaderSize : fr.pos+frameHeaderSize+int(n)]
+		fr.skip(frameHeaderSize + int(n))
+
+		return record, nil
+	}
+}
+
+// recoverFrames truncates the torn frame at the end of a framed log
+// file, left behind when the process crashed in the middle of a
+// write, and returns the resulting size of the file. Corrupt data
+// that isn't a torn frame is kept, since readers skip it anyway and
+// it may be an unframed log written before framing was enabled.
+func recoverFrames(f interface {
+	io.ReaderAt
+	io.Seeker
+	Truncate(size int64) error
+}, size int64) (int64, error) {
+	fr := NewFrameReader(io.NewSectionReader(f, 0, size))
+
+	var end int64
+	for {
+		_, err := fr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		var corrupt *CorruptFrameError
+		if errors.As(err, &corrupt) {
+			continue
+		}
+
+		if err != nil {
+			return 0, err
+		}
+
+		end = fr.Offset()
+	}
+
+	if end == size {
+		return size, nil
+	}
+
+	// The tail is torn if it is too short for a header, or its
+	// header is valid but the record doesn't fit into the file. A
+	// short tail is only trusted to be a frame if it follows one.
+	tail := size - end
+	if tail < frameHeaderSize && end == 0 {
+		return size, nil
+	}
+
+	if tail >= frameHeaderSize {
+		hdr := make([]byte, frameHeaderSize)
+
+		_, err := f.ReadAt(hdr, end)
+		if err != nil {
+			return 0, err
+		}
+
+		n, _, ok := parseFrameHeader(hdr, defaultMaxFrameSize)
+		if !ok || frameHeaderSize+int64(n) <= tail {
+			return size, nil
+		}
+	}
+
+	err := f.Truncate(end)
+	if err != nil {
+		return 0, err
+	}
+
+	// Not every filesystem moves the offset of a file opened with
+	// os.O_APPEND to its new end.
+	_, err = f.Seek(end, io.SeekStart)
+	if err != nil {
+		return 0, err
+	}
+
+	return end, nil
+}
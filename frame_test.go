@@ -0,0 +1,121 @@
+package filewriter
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+// readFrames reads every record and the number of corrupt regions.
+func readFrames(t *testing.T, r io.Reader) ([]string, int) {
+	fr := NewFrameReader(r)
+
+	var (
+		records []string
+		corrupt int
+	)
+
+	for {
+		record, err := fr.Next()
+		if errors.Is(err, io.EOF) {
+			return records, corrupt
+		}
+
+		var cerr *CorruptFrameError
+		if errors.As(err, &cerr) {
+			corrupt++
+			continue
+		}
+
+		require.NoError(t, err, "expected no error when reading frame, got '%v'", err)
+		records = append(records, string(record))
+	}
+}
+
+func TestFrameReader(t *testing.T) {
+	var stream []byte
+	stream = appendFrame(stream, []byte("one"))
+	stream = append(stream, "garbage"...)
+	stream = appendFrame(stream, []byte("two"))
+
+	damaged := appendFrame(nil, []byte("three"))
+	damaged[len(damaged)-1] ^= 0xff
+	stream = append(stream, damaged...)
+
+	stream = appendFrame(stream, []byte("four"))
+	stream = append(stream, appendFrame(nil, []byte("torn"))[:8]...)
+
+	records, corrupt := readFrames(t, bytes.NewReader(stream))
+	require.Equal(t, []string{"one", "two", "four"}, records, "unexpected records")
+	require.Equal(t, 3, corrupt, "unexpected number of corrupt regions")
+}
+
+func TestFramedWrite(t *testing.T) {
+	afs := &afero.Afero{Fs: afero.NewMemMapFs()}
+
+	fw, err := New("test.log", WithFileSystem(afs), WithFraming(true), WithLogFlushInterval(0))
+	require.NoError(t, err, "expected no error when creating file writer, got '%v'", err)
+
+	for _, record := range []string{"one", "two"} {
+		n, err := fw.Write([]byte(record))
+		require.NoError(t, err, "expected no error when writing, got '%v'", err)
+		require.Equal(t, len(record), n, "unexpected number of bytes written")
+	}
+
+	err = fw.Close()
+	require.NoError(t, err, "expected no error when closing, got '%v'", err)
+
+	f, err := afs.Open("test.log")
+	require.NoError(t, err, "expected no error when opening log file, got '%v'", err)
+	defer f.Close()
+
+	records, corrupt := readFrames(t, f)
+	require.Equal(t, []string{"one", "two"}, records, "unexpected records")
+	require.Zero(t, corrupt, "expected no corrupt frames")
+}
+
+func TestRecoverFrames(t *testing.T) {
+	afs := &afero.Afero{Fs: afero.NewMemMapFs()}
+
+	valid := appendFrame(nil, []byte("one"))
+	torn := appendFrame(nil, []byte("torn record"))[:frameHeaderSize+4]
+
+	err := afs.WriteFile("test.log", append(append([]byte(nil), valid...), torn...), defaulFileMode)
+	require.NoError(t, err, "expected no error when writing log file, got '%v'", err)
+
+	fw, err := New("test.log", WithFileSystem(afs), WithFraming(true), WithLogFlushInterval(0))
+	require.NoError(t, err, "expected no error when creating file writer, got '%v'", err)
+	require.Equal(t, uint(len(valid)), fw.Size, "expected torn frame to be truncated")
+
+	_, err = fw.Write([]byte("two"))
+	require.NoError(t, err, "expected no error when writing, got '%v'", err)
+
+	err = fw.Close()
+	require.NoError(t, err, "expected no error when closing, got '%v'", err)
+
+	data, err := afs.ReadFile("test.log")
+	require.NoError(t, err, "expected no error when reading log file, got '%v'", err)
+
+	records, corrupt := readFrames(t, bytes.NewReader(data))
+	require.Equal(t, []string{"one", "two"}, records, "unexpected records")
+	require.Zero(t, corrupt, "expected no corrupt frames")
+}
+
+func TestRecoverFramesKeepsUnframedLog(t *testing.T) {
+	afs := &afero.Afero{Fs: afero.NewMemMapFs()}
+
+	payload := []byte("Hello, world!\nHello, world!\n")
+
+	err := afs.WriteFile("test.log", payload, defaulFileMode)
+	require.NoError(t, err, "expected no error when writing log file, got '%v'", err)
+
+	fw, err := New("test.log", WithFileSystem(afs), WithFraming(true), WithLogFlushInterval(0))
+	require.NoError(t, err, "expected no error when creating file writer, got '%v'", err)
+	defer fw.Close()
+
+	require.Equal(t, uint(len(payload)), fw.Size, "expected unframed log to be kept")
+}
@@ -0,0 +1,59 @@
+package filewriter
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+)
+
+// Fs is the filesystem the FileWriter opens, renames and removes
+// log files on. It is a subset of afero.Fs, so any afero filesystem
+// can be used, for example afero.NewMemMapFs in tests. Each
+// FileWriter has its own Fs, which makes it possible to use
+// different filesystems within a single process.
+type Fs interface {
+	Open(name string) (afero.File, error)
+	OpenFile(name string, flag int, perm os.FileMode) (afero.File, error)
+	Stat(name string) (os.FileInfo, error)
+	Rename(oldname, newname string) error
+	Remove(name string) error
+	MkdirAll(path string, perm os.FileMode) error
+}
+
+var _ Fs = afero.NewOsFs()
+
+// SameFile reports whether both file infos, usually the one of an
+// open file and the one of its path, describe the same file.
+// Filesystems that don't expose the identity of their files, such as
+// afero.MemMapFs, report the current name of an open file instead,
+// which changes when it is renamed, so the base names are compared.
+func SameFile(a, b os.FileInfo) bool {
+	if a.Sys() == nil || b.Sys() == nil {
+		return filepath.Base(a.Name()) == filepath.Base(b.Name())
+	}
+
+	return os.SameFile(a, b)
+}
+
+// readDir returns the file info of every entry of the directory.
+func readDir(fs Fs, dir string) ([]os.FileInfo, error) {
+	d, err := fs.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer d.Close()
+
+	return d.Readdir(-1)
+}
+
+// splitDir splits the file name into its directory and base name,
+// using "." for the directory of a relative name without one.
+func splitDir(name string) (string, string) {
+	dir, base := filepath.Split(name)
+	if dir == "" {
+		dir = "."
+	}
+
+	return dir, base
+}
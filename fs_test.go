@@ -0,0 +1,38 @@
+package filewriter
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileSystemPerWriter(t *testing.T) {
+	fileName := "logs/test.log"
+	payload := []byte("Hello, world!\n")
+
+	first := &afero.Afero{Fs: afero.NewMemMapFs()}
+	second := &afero.Afero{Fs: afero.NewMemMapFs()}
+
+	for _, afs := range []*afero.Afero{first, second} {
+		fw, err := New(fileName, WithFileSystem(afs), WithLogFlushInterval(0))
+		require.NoError(t, err, "expected no error when creating file writer, got '%v'", err)
+
+		_, err = fw.Write(payload)
+		require.NoError(t, err, "expected no error when writing, got '%v'", err)
+
+		err = fw.Close()
+		require.NoError(t, err, "expected no error when closing, got '%v'", err)
+	}
+
+	for _, afs := range []*afero.Afero{first, second} {
+		content, err := afs.ReadFile(fileName)
+		require.NoError(t, err, "expected no error when reading file, got '%v'", err)
+
+		require.Equal(
+			t, payload, content,
+			"expected file to hold '%v', got '%v'",
+			string(payload), string(content),
+		)
+	}
+}
@@ -0,0 +1,115 @@
+package filewriter
+
+import (
+	"sync"
+	"time"
+)
+
+// RotateEvent describes a rotation of the log file.
+type RotateEvent struct {
+	Path   string    // the path of the log file
+	Backup string    // the path the file was renamed to, empty if it was deleted
+	Size   uint      // the size of the rotated file (in bytes)
+	Start  time.Time // the time the rotated file was started
+	End    time.Time // the time the rotated file was rotated
+	Codec  string    // the name of the codec the backup is compressed with, if any
+}
+
+// CompressEvent describes the compression of a rotated log file.
+// If compression failed, Err is set and the uncompressed Source is
+// kept.
+type CompressEvent struct {
+	Source         string // the path of the uncompressed backup
+	Backup         string // the path of the compressed backup
+	Size           int64  // the size of Source (in bytes)
+	CompressedSize int64  // the size of Backup (in bytes)
+	Codec          string // the name of the codec
+	Err            error
+}
+
+// PruneEvent describes a removal of the backups exceeding the
+// retention limits. If some backups couldn't be removed, Err is set
+// and they are missing from Removed.
+type PruneEvent struct {
+	Path    string   // the path of the log file
+	Removed []string // the paths of the removed backups
+	Err     error
+}
+
+// hookQueue holds the hooks waiting to be run. Hooks are queued
+// while fw.mu is held and run once it is released, so other writers
+// aren't blocked by them. They run on the goroutine that triggered
+// them, though: a slow hook, such as an upload of the backup, stalls
+// the Write, Flush or tick that rotated the file, or the whole queue
+// in asynchronous mode, so it should hand its work off to a goroutine
+// of its own.
+type hookQueue struct {
+	mu      sync.Mutex
+	pending []func()
+
+	// running is held by the goroutine running the hooks, which
+	// keeps them in the order they were queued.
+	running sync.Mutex
+}
+
+func (q *hookQueue) push(fn func()) {
+	q.mu.Lock()
+	q.pending = append(q.pending, fn)
+	q.mu.Unlock()
+}
+
+func (q *hookQueue) take() []func() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	pending := q.pending
+	q.pending = nil
+
+	return pending
+}
+
+func (q *hookQueue) hasPending() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return len(q.pending) > 0
+}
+
+// runHooks runs the queued hooks. It must be called without holding
+// fw.mu. If another goroutine is already running hooks, it returns
+// immediately, leaving the queued ones to that goroutine.
+func (fw *FileWriter) runHooks() {
+	for fw.hooks.hasPending() && fw.hooks.running.TryLock() {
+		for {
+			pending := fw.hooks.take()
+			if len(pending) == 0 {
+				break
+			}
+
+			for _, fn := range pending {
+				fn()
+			}
+		}
+
+		// Hooks queued after the last take but before the unlock are
+		// picked up by the next iteration of the outer loop.
+		fw.hooks.running.Unlock()
+	}
+}
+
+// queueHooks queues a call of every hook with the event. Errors
+// returned by the hooks are reported through errorHandler.
+func queueHooks[E any](fw *FileWriter, hooks []func(E) error, event E, errorHandler func(fw *FileWriter, err error)) {
+	if len(hooks) == 0 {
+		return
+	}
+
+	fw.hooks.push(func() {
+		for _, hook := range hooks {
+			err := hook(event)
+			if err != nil {
+				errorHandler(fw, err)
+			}
+		}
+	})
+}
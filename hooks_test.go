@@ -0,0 +1,89 @@
+package filewriter
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHooks(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		rotated   []RotateEvent
+		compress  []CompressEvent
+		pruned    []PruneEvent
+		handled   []error
+		hookError = errors.New("hook failed")
+	)
+
+	fw, err := New(
+		"test.log",
+		WithFileSystem(afero.NewMemMapFs()),
+		WithLogFlushInterval(0),
+		WithFileMaxBackups(1),
+		WithOnRotate(func(e RotateEvent) error {
+			mu.Lock()
+			defer mu.Unlock()
+
+			rotated = append(rotated, e)
+			return hookError
+		}),
+		WithOnCompressed(func(e CompressEvent) error {
+			mu.Lock()
+			defer mu.Unlock()
+
+			compress = append(compress, e)
+			return nil
+		}),
+		WithOnPruned(func(e PruneEvent) error {
+			mu.Lock()
+			defer mu.Unlock()
+
+			pruned = append(pruned, e)
+			return nil
+		}),
+		WithErrorHandler(func(fw *FileWriter, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			handled = append(handled, err)
+		}),
+	)
+	require.NoError(t, err, "expected no error when creating file writer, got '%v'", err)
+	defer fw.Close()
+
+	payload := []byte("Hello, world!\n")
+	for range 2 {
+		_, err = fw.Write(payload)
+		require.NoError(t, err, "expected no error when writing, got '%v'", err)
+
+		err = fw.Rotate()
+		require.NoError(t, err, "expected no error when rotating, got '%v'", err)
+	}
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return len(pruned) == 1
+	}, time.Second, 10*time.Millisecond, "expected backups to be pruned")
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	require.Len(t, rotated, 2, "expected two rotate events")
+	require.Equal(t, "test.log", rotated[0].Path, "unexpected rotated path")
+	require.Equal(t, uint(len(payload)), rotated[0].Size, "unexpected rotated size")
+	require.Equal(t, "gzip", rotated[0].Codec, "unexpected rotated codec")
+
+	require.Len(t, compress, 2, "expected two compress events")
+	require.NoError(t, compress[0].Err, "expected compression to succeed")
+	require.Equal(t, rotated[0].Backup+".gz", compress[0].Backup, "unexpected compressed backup")
+
+	require.Equal(t, []string{compress[0].Backup}, pruned[0].Removed, "expected the oldest backup to be pruned")
+	require.Equal(t, []error{hookError, hookError}, handled, "expected hook errors to be handled")
+}
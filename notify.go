@@ -0,0 +1,47 @@
+package filewriter
+
+import "sync"
+
+// subscribers holds the channels of the goroutines following the
+// log file in-process.
+type subscribers struct {
+	mu  sync.Mutex
+	chs map[chan struct{}]struct{}
+}
+
+// notify wakes every subscriber up without blocking; a subscriber
+// that hasn't received the previous notification yet gets only one.
+func (s *subscribers) notify() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for ch := range s.chs {
+		wake(ch)
+	}
+}
+
+// Subscribe returns a channel that receives a value whenever data
+// is flushed to the log file or the file is rotated or reopened, so
+// that a reader following the log doesn't have to poll it. Values
+// are coalesced: a subscriber that lags behind receives a single
+// one. The returned function cancels the subscription.
+func (fw *FileWriter) Subscribe() (ch <-chan struct{}, cancel func()) {
+	c := make(chan struct{}, 1)
+
+	fw.subs.mu.Lock()
+	if fw.subs.chs == nil {
+		fw.subs.chs = make(map[chan struct{}]struct{})
+	}
+	fw.subs.chs[c] = struct{}{}
+	fw.subs.mu.Unlock()
+
+	var once sync.Once
+
+	return c, func() {
+		once.Do(func() {
+			fw.subs.mu.Lock()
+			delete(fw.subs.chs, c)
+			fw.subs.mu.Unlock()
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package filewriter
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscribe(t *testing.T) {
+	fw, err := New(
+		"test.log",
+		WithFileSystem(afero.NewMemMapFs()),
+		WithLogFlushInterval(0),
+		WithLogMaxBatchSize(1),
+	)
+	require.NoError(t, err, "expected no error when creating file writer, got '%v'", err)
+	defer fw.Close()
+
+	ch, cancel := fw.Subscribe()
+
+	_, err = fw.Write([]byte("data\n"))
+	require.NoError(t, err, "expected no error when writing, got '%v'", err)
+
+	select {
+	case <-ch:
+	default:
+		t.Fatal("expected a notification after flush")
+	}
+
+	cancel()
+
+	err = fw.Rotate()
+	require.NoError(t, err, "expected no error when rotating, got '%v'", err)
+
+	select {
+	case <-ch:
+		t.Fatal("expected no notification after cancel")
+	default:
+	}
+}
@@ -1,61 +1,328 @@
 package filewriter
 
 import (
+	"io"
 	"os"
 	"time"
 )
 
-type Option func(*FileWriter)
+// Option changes a setting of the Config. It returns an error if
+// its argument is invalid; New collects the errors of all the
+// options into a *ConfigError.
+type Option func(*Config) error
+
+// WithConfig replaces the whole Config, e.g. one built with
+// NewConfig or loaded from a file. The options following it change
+// the settings of the given Config.
+func WithConfig(c Config) Option {
+	return func(cfg *Config) error {
+		*cfg = c
+		return nil
+	}
+}
 
 func WithFileWriterFileMode(mode int) Option {
-	return func(fw *FileWriter) {
-		fw.Mode = os.FileMode(mode)
+	return func(c *Config) error {
+		if mode < 0 {
+			return &SettingError{Setting: "Mode", Value: mode, Reason: "must not be negative"}
+		}
+
+		c.Mode = os.FileMode(mode)
+		return nil
 	}
 }
 
 func WithFileDeleteOld(delete bool) Option {
-	return func(fw *FileWriter) {
-		fw.DeleteOld = delete
+	return func(c *Config) error {
+		c.DeleteOld = delete
+		return nil
 	}
 }
 
 func WithFileRotatePostfix(postfix string) Option {
-	return func(fw *FileWriter) {
-		fw.RotatePostfix = postfix
+	return func(c *Config) error {
+		c.RotatePostfix = postfix
+		return nil
 	}
 }
 
 func WithFileCompress(compress bool) Option {
-	return func(fw *FileWriter) {
-		fw.Compress = compress
+	return func(c *Config) error {
+		c.Compress = compress
+		return nil
 	}
 }
 
+// WithFileMaxSize sets the maximum size of the log file in
+// megabytes.
 func WithFileMaxSize(size float64) Option {
-	return func(fw *FileWriter) {
-		fw.MaxSize = uint(size * 1024 * 1024)
+	return func(c *Config) error {
+		if size <= 0 {
+			return &SettingError{Setting: "MaxSize", Value: size, Reason: "must be positive"}
+		}
+
+		c.MaxSize = uint(size * 1024 * 1024)
+		return nil
 	}
 }
 
+// WithLogMaxBatchSize flushes the buffer once the given number of
+// log entries is buffered, 0 disables the trigger. Prefer
+// WithFlushBytes, since the number of entries says little about the
+// amount of buffered data.
 func WithLogMaxBatchSize(size int) Option {
-	return func(fw *FileWriter) {
-		fw.MaxBatchSize = size
+	return func(c *Config) error {
+		c.MaxBatchSize = size
+		return nil
 	}
 }
 
+// WithBufferSize sets the capacity of the log buffer in bytes. A
+// record larger than the buffer is written to the log file directly.
+func WithBufferSize(size int) Option {
+	return func(c *Config) error {
+		c.BufSize = size
+		return nil
+	}
+}
+
+// WithFlushBytes flushes the buffer once at least n bytes are
+// buffered. It has no effect if n is not less than the buffer size,
+// since a full buffer is flushed anyway.
+func WithFlushBytes(n uint) Option {
+	return func(c *Config) error {
+		c.FlushBytes = n
+		return nil
+	}
+}
+
+// WithFlushLatency bounds the time data waits in the buffer: the
+// buffer is flushed once its oldest data has waited for the given
+// duration, even if nothing else is written.
+func WithFlushLatency(latency time.Duration) Option {
+	return func(c *Config) error {
+		c.FlushLatency = latency
+		return nil
+	}
+}
+
+// WithLogFlushInterval sets the interval at which the buffer is
+// flushed, 0 disables periodic flushes.
 func WithLogFlushInterval(interval time.Duration) Option {
-	return func(fw *FileWriter) {
-		if interval == 0 {
-			fw.FlushTicker = nil
-			return
+	return func(c *Config) error {
+		c.FlushInterval = interval
+		return nil
+	}
+}
+
+func WithErrorHandler(h func(fw *FileWriter, err error)) Option {
+	return func(c *Config) error {
+		c.ErrorHandler = h
+		return nil
+	}
+}
+
+// WithRotatePolicy sets the policy that decides when the log file
+// is rotated. Use AnyPolicy to combine size and time triggers.
+func WithRotatePolicy(p RotatePolicy) Option {
+	return func(c *Config) error {
+		c.RotatePolicy = p
+		return nil
+	}
+}
+
+func WithFileMaxBackups(n int) Option {
+	return func(c *Config) error {
+		c.MaxBackups = n
+		return nil
+	}
+}
+
+func WithFileMaxAge(age time.Duration) Option {
+	return func(c *Config) error {
+		c.MaxAge = age
+		return nil
+	}
+}
+
+// WithFileMaxTotalSize sets the maximum total size of the backups in
+// megabytes, 0 is unlimited.
+func WithFileMaxTotalSize(size float64) Option {
+	return func(c *Config) error {
+		if size < 0 {
+			return &SettingError{Setting: "MaxTotalSize", Value: size, Reason: "must not be negative"}
 		}
 
-		fw.FlushTicker = time.NewTicker(interval)
+		c.MaxTotalSize = uint(size * 1024 * 1024)
+		return nil
 	}
 }
 
-func WithErrorHandler(h func(fw *FileWriter, err error)) Option {
-	return func(fw *FileWriter) {
-		fw.ErrorHandler = h
+// WithFileCompressAsync sets the number of background workers
+// compressing rotated files and the number of files that may wait
+// for them. Rotation only renames the log file and queues it for
+// compression; if more than queueSize files are waiting, the file is
+// left uncompressed until the queue has room again.
+func WithFileCompressAsync(workers, queueSize int) Option {
+	return func(c *Config) error {
+		c.CompressWorkers = workers
+		c.CompressQueueSize = queueSize
+		return nil
+	}
+}
+
+func WithFileCompressWait(wait bool) Option {
+	return func(c *Config) error {
+		c.WaitCompress = wait
+		return nil
+	}
+}
+
+// WithFileCodec sets the codec used to compress rotated files,
+// for example Gzip, Zstd, S2, Snappy or LZ4.
+func WithFileCodec(codec Codec) Option {
+	return func(c *Config) error {
+		c.Codec = codec
+		return nil
+	}
+}
+
+func WithFileCompressLevel(level CompressionLevel) Option {
+	return func(c *Config) error {
+		c.CompressLevel = level
+		return nil
+	}
+}
+
+// WithFileSystem sets the filesystem the log files are stored on.
+// Any afero.Fs can be passed.
+func WithFileSystem(fs Fs) Option {
+	return func(c *Config) error {
+		c.Fs = fs
+		return nil
+	}
+}
+
+// WithSyncMode sets when the log file is fsynced. For SyncEveryBytes
+// and SyncEveryInterval the threshold is set with WithSyncBytes and
+// WithSyncInterval respectively.
+func WithSyncMode(mode SyncMode) Option {
+	return func(c *Config) error {
+		c.SyncMode = mode
+		return nil
+	}
+}
+
+func WithSyncBytes(n uint) Option {
+	return func(c *Config) error {
+		c.SyncBytes = n
+		return nil
+	}
+}
+
+func WithSyncInterval(interval time.Duration) Option {
+	return func(c *Config) error {
+		c.SyncInterval = interval
+		return nil
+	}
+}
+
+// WithFileWatch enables detection of external rotation. On every
+// tick of the flush ticker the log file path is checked, and the
+// file is reopened if it was moved or deleted, or its size is
+// resynchronized if it was truncated. Watching requires a positive
+// flush interval.
+func WithFileWatch(watch bool) Option {
+	return func(c *Config) error {
+		c.WatchFile = watch
+		return nil
+	}
+}
+
+// WithOversizePolicy sets how a record larger than the maximum log
+// file size is written. The marker appended to records truncated by
+// OversizeTruncate is set with WithTruncateMarker.
+func WithOversizePolicy(policy OversizePolicy) Option {
+	return func(c *Config) error {
+		c.OversizePolicy = policy
+		return nil
+	}
+}
+
+func WithTruncateMarker(marker string) Option {
+	return func(c *Config) error {
+		c.TruncateMarker = []byte(marker)
+		return nil
+	}
+}
+
+// WithAsyncWrite makes Write asynchronous: records are copied into
+// a queue of the given size and written to the log file by a
+// background goroutine. The policy defines what happens when the
+// queue is full; OverflowSpill requires WithSpillWriter.
+func WithAsyncWrite(queueSize int, policy OverflowPolicy) Option {
+	return func(c *Config) error {
+		c.AsyncQueueSize = queueSize
+		c.OverflowPolicy = policy
+		return nil
+	}
+}
+
+func WithSpillWriter(w io.Writer) Option {
+	return func(c *Config) error {
+		c.SpillWriter = w
+		return nil
+	}
+}
+
+// WithOnRotate registers a hook called after the log file is
+// rotated. Hooks are called outside of the lock, in the order they
+// were registered, and their errors are reported through the
+// ErrorHandler. They run on the goroutine whose write rotated the
+// file, so a slow hook should do its work in the background.
+func WithOnRotate(hook func(RotateEvent) error) Option {
+	return func(c *Config) error {
+		c.OnRotate = append(c.OnRotate, hook)
+		return nil
+	}
+}
+
+// WithOnCompressed registers a hook called after a rotated file is
+// compressed or fails to be compressed.
+func WithOnCompressed(hook func(CompressEvent) error) Option {
+	return func(c *Config) error {
+		c.OnCompressed = append(c.OnCompressed, hook)
+		return nil
+	}
+}
+
+// WithOnPruned registers a hook called after backups exceeding the
+// retention limits are removed.
+func WithOnPruned(hook func(PruneEvent) error) Option {
+	return func(c *Config) error {
+		c.OnPruned = append(c.OnPruned, hook)
+		return nil
+	}
+}
+
+// WithFraming stores every Write as a frame carrying the length and
+// the CRC-32C of the record, so that a torn or damaged record can be
+// detected and skipped by a FrameReader. When the log file is opened,
+// a frame torn by a crash at its end is truncated before appending.
+func WithFraming(framed bool) Option {
+	return func(c *Config) error {
+		c.Framed = framed
+		return nil
+	}
+}
+
+// WithUrgent sets the predicate reporting whether a record is
+// urgent; an urgent record is flushed to the log file right after it
+// is written instead of waiting for the batch or the flush ticker.
+// See JSONFieldIn for a predicate matching the level of a JSON log.
+func WithUrgent(fn func(p []byte) bool) Option {
+	return func(c *Config) error {
+		c.Urgent = fn
+		return nil
 	}
 }
@@ -0,0 +1,95 @@
+package filewriter
+
+import "sync/atomic"
+
+// queueSlot is a cell of the ring queue. Its sequence number tells
+// whether the cell is ready to be pushed to or popped from during
+// the current lap around the ring.
+type queueSlot struct {
+	seq atomic.Uint64
+	rec []byte
+}
+
+// ringQueue is a bounded lock-free multi-producer multi-consumer
+// queue based on the algorithm by Dmitry Vyukov. Producers and
+// consumers only contend on the head and tail counters, which are
+// advanced with compare-and-swap.
+type ringQueue struct {
+	mask  uint64
+	slots []queueSlot
+
+	_    [56]byte // keeps head and tail on separate cache lines
+	head atomic.Uint64
+	_    [56]byte
+	tail atomic.Uint64
+}
+
+// newRingQueue creates a queue holding at least size records; the
+// capacity is rounded up to a power of two.
+func newRingQueue(size int) *ringQueue {
+	capacity := 1
+	for capacity < size {
+		capacity <<= 1
+	}
+
+	q := &ringQueue{
+		mask:  uint64(capacity - 1),
+		slots: make([]queueSlot, capacity),
+	}
+
+	for i := range q.slots {
+		q.slots[i].seq.Store(uint64(i))
+	}
+
+	return q
+}
+
+// push appends the record to the queue. It returns false if the
+// queue is full.
+func (q *ringQueue) push(rec []byte) bool {
+	for {
+		pos := q.tail.Load()
+		slot := &q.slots[pos&q.mask]
+		diff := int64(slot.seq.Load()) - int64(pos)
+
+		switch {
+		case diff == 0:
+			if q.tail.CompareAndSwap(pos, pos+1) {
+				slot.rec = rec
+				slot.seq.Store(pos + 1)
+				return true
+			}
+		case diff < 0:
+			return false
+		}
+	}
+}
+
+// pop removes the oldest record from the queue. It returns false if
+// the queue is empty.
+func (q *ringQueue) pop() ([]byte, bool) {
+	for {
+		pos := q.head.Load()
+		slot := &q.slots[pos&q.mask]
+		diff := int64(slot.seq.Load()) - int64(pos+1)
+
+		switch {
+		case diff == 0:
+			if q.head.CompareAndSwap(pos, pos+1) {
+				rec := slot.rec
+				slot.rec = nil
+				slot.seq.Store(pos + q.mask + 1)
+				return rec, true
+			}
+		case diff < 0:
+			return nil, false
+		}
+	}
+}
+
+// len returns the number of records in the queue. It is only exact
+// when no push or pop runs concurrently.
+func (q *ringQueue) len() int {
+	head := q.head.Load()
+	return int(q.tail.Load() - head)
+}
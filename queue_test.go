@@ -0,0 +1,77 @@
+package filewriter
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRingQueue(t *testing.T) {
+	q := newRingQueue(3)
+	require.Len(t, q.slots, 4, "expected capacity to be rounded up to a power of two")
+
+	for i := range 4 {
+		require.True(t, q.push([]byte{byte(i)}), "expected push %v to succeed", i)
+	}
+
+	require.False(t, q.push([]byte{4}), "expected push to a full queue to fail")
+
+	for i := range 4 {
+		rec, ok := q.pop()
+		require.True(t, ok, "expected pop %v to succeed", i)
+		require.Equal(t, []byte{byte(i)}, rec, "expected records in FIFO order")
+	}
+
+	_, ok := q.pop()
+	require.False(t, ok, "expected pop from an empty queue to fail")
+}
+
+func TestRingQueueConcurrent(t *testing.T) {
+	const (
+		producers = 4
+		records   = 1000
+	)
+
+	q := newRingQueue(16)
+
+	var wg sync.WaitGroup
+	wg.Add(producers)
+
+	for p := range producers {
+		go func() {
+			defer wg.Done()
+
+			for i := range records {
+				for !q.push([]byte{byte(p), byte(i)}) {
+					runtime.Gosched()
+				}
+			}
+		}()
+	}
+
+	popped := 0
+	last := make([]int, producers)
+	for i := range last {
+		last[i] = -1
+	}
+
+	for popped < producers*records {
+		rec, ok := q.pop()
+		if !ok {
+			runtime.Gosched()
+			continue
+		}
+
+		// The order of records of a single producer is preserved,
+		// although records of different producers interleave.
+		p, i := int(rec[0]), int(rec[1])
+		require.Equal(t, (last[p]+1)%256, i, "expected records of producer %v in order", p)
+
+		last[p] = i
+		popped++
+	}
+
+	wg.Wait()
+}
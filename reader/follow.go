@@ -0,0 +1,276 @@
+package reader
+
+import (
+	"errors"
+	"io"
+	"iter"
+	"os"
+	"sync"
+	"time"
+
+	filewriter "github.com/mtchuikov/file-writer"
+)
+
+const defaultPollInterval = 250 * time.Millisecond
+
+// WithFromStart makes a Follower start at the beginning of the live
+// log file instead of its end.
+func WithFromStart(fromStart bool) Option {
+	return func(c *config) {
+		c.fromStart = fromStart
+	}
+}
+
+// WithOffset makes a Follower start at the given byte offset of the
+// live log file, e.g. where a previous read of the file ended. If the
+// file is shorter than that, the Follower starts at its beginning.
+func WithOffset(offset int64) Option {
+	return func(c *config) {
+		c.startOffset = offset
+	}
+}
+
+// WithPollInterval sets how often a Follower checks the log file for
+// new data and rotation. It is also used as a fallback when the
+// Follower is subscribed to a FileWriter with WithWriter.
+func WithPollInterval(interval time.Duration) Option {
+	return func(c *config) {
+		c.pollInterval = interval
+	}
+}
+
+// WithWriter subscribes a Follower to a FileWriter of the same
+// process, which wakes it up as soon as data is flushed or the file
+// is rotated instead of waiting for the next poll. The filesystem of
+// the FileWriter is used unless another one is set with WithOptions.
+func WithWriter(fw *filewriter.FileWriter) Option {
+	return func(c *config) {
+		c.writer = fw
+		c.fwOpts = append([]filewriter.Option{filewriter.WithFileSystem(fw.Fs)}, c.fwOpts...)
+	}
+}
+
+// liveFile is the subset of afero.File a Follower needs.
+type liveFile interface {
+	io.ReadCloser
+	io.Seeker
+	Stat() (os.FileInfo, error)
+}
+
+// Follower follows the live log file the way tail -F does. When the
+// file is rotated, the rest of the old file is read before the
+// Follower continues with the new one, so no line is lost or read
+// twice. When the file is truncated in place, the Follower starts
+// over from its beginning.
+type Follower struct {
+	config
+	live filewriter.Segment
+
+	// mu is held by Read for its whole duration, so that Close
+	// doesn't close the file under it.
+	mu     sync.Mutex
+	file   liveFile
+	offset int64
+
+	notify    <-chan struct{}
+	cancel    func()
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// Follow starts following the live log file with the given name. The
+// file doesn't have to exist yet.
+func Follow(name string, opts ...Option) (*Follower, error) {
+	f := &Follower{
+		config: config{pollInterval: defaultPollInterval},
+		done:   make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(&f.config)
+	}
+
+	f.live = filewriter.LiveSegment(name, f.fwOpts...)
+
+	if f.writer != nil {
+		f.notify, f.cancel = f.writer.Subscribe()
+	}
+
+	err := f.open()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	if f.file == nil || (f.fromStart && f.startOffset == 0) {
+		return f, nil
+	}
+
+	if f.startOffset > 0 {
+		f.offset, err = f.file.Seek(f.startOffset, io.SeekStart)
+	} else {
+		f.offset, err = f.file.Seek(0, io.SeekEnd)
+	}
+
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// open opens the file currently at the path of the live segment,
+// leaving f.file nil if there is none yet.
+func (f *Follower) open() error {
+	rc, err := f.live.Open()
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	f.file = rc.(liveFile)
+	f.offset = 0
+
+	return nil
+}
+
+// Read implements io.Reader. It blocks until new data is written to
+// the log file and returns io.EOF only after the Follower is closed.
+func (f *Follower) Read(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for {
+		if f.closed() {
+			return 0, io.EOF
+		}
+
+		if f.file != nil {
+			n, err := f.file.Read(p)
+			f.offset += int64(n)
+
+			if n > 0 {
+				return n, nil
+			}
+
+			// Reading past the end of a truncated file fails with
+			// io.ErrUnexpectedEOF on some filesystems.
+			eof := errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+			if err != nil && !eof {
+				return 0, err
+			}
+		}
+
+		// The end of the file was reached, so the file is checked
+		// for rotation before waiting for more data.
+		switched, err := f.check()
+		if err != nil {
+			return 0, err
+		}
+
+		if !switched {
+			f.wait()
+		}
+	}
+}
+
+// check detects rotation and truncation of the live file. It reports
+// whether the Follower moved on to another file or position, in
+// which case reading should be retried immediately.
+func (f *Follower) check() (bool, error) {
+	stat, err := f.live.Stat()
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	if f.file == nil {
+		return true, f.open()
+	}
+
+	current, err := f.file.Stat()
+	if err != nil {
+		return false, err
+	}
+
+	if !filewriter.SameFile(current, stat) {
+		// The file was rotated. The writer flushes before renaming
+		// the file, so anything written after the end was reached
+		// is already there and is read before switching.
+		if current.Size() > f.offset {
+			return true, nil
+		}
+
+		f.file.Close()
+		f.file = nil
+
+		return true, f.open()
+	}
+
+	if stat.Size() < f.offset {
+		f.offset, err = f.file.Seek(0, io.SeekStart)
+		return true, err
+	}
+
+	return false, nil
+}
+
+// wait blocks until the poll interval passes, the subscribed
+// FileWriter flushes or rotates, or the Follower is closed.
+func (f *Follower) wait() {
+	timer := time.NewTimer(f.pollInterval)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-f.notify:
+	case <-f.done:
+	}
+}
+
+func (f *Follower) closed() bool {
+	select {
+	case <-f.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Lines returns an iterator over the lines appended to the log file,
+// including their trailing newline. It blocks waiting for new lines
+// and ends once the Follower is closed. The line is only valid until
+// the next iteration.
+func (f *Follower) Lines() iter.Seq2[[]byte, error] {
+	return lines(f, f.inRange)
+}
+
+// Close stops following the log file, unblocking a pending Read.
+func (f *Follower) Close() error {
+	f.closeOnce.Do(func() {
+		close(f.done)
+	})
+
+	if f.cancel != nil {
+		f.cancel()
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+
+	err := f.file.Close()
+	f.file = nil
+
+	return err
+}
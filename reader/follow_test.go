@@ -0,0 +1,118 @@
+package reader
+
+import (
+	"testing"
+	"time"
+
+	filewriter "github.com/mtchuikov/file-writer"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+// collectLines reads n lines from the follower in the background.
+func collectLines(f *Follower, n int) <-chan []string {
+	ch := make(chan []string, 1)
+
+	go func() {
+		var lines []string
+		for line, err := range f.Lines() {
+			if err != nil {
+				break
+			}
+
+			lines = append(lines, string(line))
+			if len(lines) == n {
+				break
+			}
+		}
+
+		ch <- lines
+	}()
+
+	return ch
+}
+
+func TestFollowerRotation(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	fw, err := filewriter.New(
+		"test.log",
+		filewriter.WithFileSystem(fs),
+		filewriter.WithLogFlushInterval(0),
+		filewriter.WithLogMaxBatchSize(1),
+	)
+	require.NoError(t, err, "expected no error when creating file writer, got '%v'", err)
+	defer fw.Close()
+
+	_, err = fw.Write([]byte("skipped\n"))
+	require.NoError(t, err, "expected no error when writing, got '%v'", err)
+
+	f, err := Follow("test.log", WithWriter(fw), WithPollInterval(time.Hour))
+	require.NoError(t, err, "expected no error when following, got '%v'", err)
+	defer f.Close()
+
+	ch := collectLines(f, 3)
+
+	for i, line := range []string{"one\n", "two\n", "three\n"} {
+		if i == 2 {
+			err = fw.Rotate()
+			require.NoError(t, err, "expected no error when rotating, got '%v'", err)
+		}
+
+		_, err = fw.Write([]byte(line))
+		require.NoError(t, err, "expected no error when writing, got '%v'", err)
+	}
+
+	select {
+	case lines := <-ch:
+		require.Equal(t, []string{"one\n", "two\n", "three\n"}, lines, "unexpected lines")
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for lines")
+	}
+}
+
+func TestFollowerTruncation(t *testing.T) {
+	afs := &afero.Afero{Fs: afero.NewMemMapFs()}
+
+	err := afs.WriteFile("test.log", []byte("one\ntwo\n"), 0o644)
+	require.NoError(t, err, "expected no error when creating log file, got '%v'", err)
+
+	f, err := Follow("test.log",
+		WithOptions(filewriter.WithFileSystem(afs.Fs)),
+		WithFromStart(true),
+		WithPollInterval(time.Millisecond),
+	)
+	require.NoError(t, err, "expected no error when following, got '%v'", err)
+	defer f.Close()
+
+	ch := collectLines(f, 3)
+
+	time.Sleep(10 * time.Millisecond)
+
+	err = afs.WriteFile("test.log", []byte("x\n"), 0o644)
+	require.NoError(t, err, "expected no error when truncating log file, got '%v'", err)
+
+	select {
+	case lines := <-ch:
+		require.Equal(t, []string{"one\n", "two\n", "x\n"}, lines, "unexpected lines")
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for lines")
+	}
+}
+
+func TestFollowerClose(t *testing.T) {
+	f, err := Follow("test.log", WithOptions(filewriter.WithFileSystem(afero.NewMemMapFs())))
+	require.NoError(t, err, "expected no error when following, got '%v'", err)
+
+	ch := collectLines(f, 1)
+
+	err = f.Close()
+	require.NoError(t, err, "expected no error when closing, got '%v'", err)
+
+	select {
+	case lines := <-ch:
+		require.Empty(t, lines, "expected no lines")
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the follower to stop")
+	}
+}
@@ -0,0 +1,300 @@
+// Package reader reads a log written by a FileWriter as a single
+// stream, going through the rotated backups from the oldest to the
+// newest one and finishing with the live log file.
+package reader
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"iter"
+	"strings"
+	"time"
+
+	filewriter "github.com/mtchuikov/file-writer"
+)
+
+// config holds the settings shared by Reader and Follower.
+type config struct {
+	fwOpts    []filewriter.Option
+	from, to  time.Time
+	timestamp func(line []byte) (time.Time, bool)
+
+	fromStart    bool
+	startOffset  int64
+	pollInterval time.Duration
+	writer       *filewriter.FileWriter
+}
+
+// Option configures a Reader or a Follower.
+type Option func(*config)
+
+// WithOptions sets the options the log was written with, so that the
+// Reader finds the backups the same way the FileWriter names them.
+func WithOptions(opts ...filewriter.Option) Option {
+	return func(c *config) {
+		c.fwOpts = append(c.fwOpts, opts...)
+	}
+}
+
+// WithTimeRange limits the Reader to the segments overlapping the
+// range [from, to). A zero from or to leaves the range unbounded on
+// that side. Segments are filtered as a whole; use WithTimestamp to
+// filter individual lines as well.
+func WithTimeRange(from, to time.Time) Option {
+	return func(c *config) {
+		c.from = from
+		c.to = to
+	}
+}
+
+// WithTimestamp sets the function extracting the timestamp of a
+// line. When set, Lines skips the lines outside of the time range.
+// Lines without a timestamp, such as the continuation lines of a
+// stack trace, are never skipped.
+func WithTimestamp(fn func(line []byte) (time.Time, bool)) Option {
+	return func(c *config) {
+		c.timestamp = fn
+	}
+}
+
+// Reader streams the segments of a log one after another,
+// transparently decompressing the compressed backups.
+type Reader struct {
+	config
+	segments []filewriter.Segment
+
+	next int
+	cur  io.ReadCloser
+}
+
+// Open discovers the segments of the log file with the given name
+// and returns a Reader positioned at the start of the oldest one.
+func Open(name string, opts ...Option) (*Reader, error) {
+	r := &Reader{}
+
+	for _, opt := range opts {
+		opt(&r.config)
+	}
+
+	segments, err := filewriter.Segments(name, r.fwOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range segments {
+		if r.overlaps(s) {
+			r.segments = append(r.segments, s)
+		}
+	}
+
+	return r, nil
+}
+
+// Segments returns the segments the Reader goes through, ordered
+// from the oldest to the newest one.
+func (r *Reader) Segments() []filewriter.Segment {
+	return r.segments
+}
+
+// Read implements io.Reader, reading the segments one after another.
+func (r *Reader) Read(p []byte) (int, error) {
+	for {
+		if r.cur == nil {
+			if r.next >= len(r.segments) {
+				return 0, io.EOF
+			}
+
+			rc, err := r.segments[r.next].Open()
+			if err != nil {
+				return 0, err
+			}
+
+			r.cur = rc
+			r.next++
+		}
+
+		n, err := r.cur.Read(p)
+		if errors.Is(err, io.EOF) {
+			err = r.cur.Close()
+			r.cur = nil
+
+			if n > 0 || err != nil {
+				return n, err
+			}
+
+			continue
+		}
+
+		return n, err
+	}
+}
+
+// Lines returns an iterator over the lines of the log, including
+// their trailing newline. The iteration stops at the first error,
+// which is yielded with a nil line. The line is only valid until the
+// next iteration.
+func (r *Reader) Lines() iter.Seq2[[]byte, error] {
+	return lines(r, r.inRange)
+}
+
+// Records returns an iterator over the records of a log written with
+// framing enabled. Corrupt data is skipped and reported with a
+// *filewriter.CorruptFrameError and a nil record, after which the
+// iteration may go on; any other error stops it. The record is only
+// valid until the next iteration.
+func (r *Reader) Records() iter.Seq2[[]byte, error] {
+	return func(yield func([]byte, error) bool) {
+		fr := filewriter.NewFrameReader(r)
+
+		for {
+			record, err := fr.Next()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+
+			var corrupt *filewriter.CorruptFrameError
+			if err != nil && !errors.As(err, &corrupt) {
+				yield(nil, err)
+				return
+			}
+
+			if !yield(record, err) {
+				return
+			}
+		}
+	}
+}
+
+// Close closes the segment being read.
+func (r *Reader) Close() error {
+	r.next = len(r.segments)
+
+	if r.cur == nil {
+		return nil
+	}
+
+	err := r.cur.Close()
+	r.cur = nil
+
+	return err
+}
+
+// overlaps reports whether the segment overlaps the time range. An
+// unknown start or the end of the live file is considered unbounded.
+func (c *config) overlaps(s filewriter.Segment) bool {
+	if !c.to.IsZero() && !s.Start.IsZero() && !s.Start.Before(c.to) {
+		return false
+	}
+
+	if !c.from.IsZero() && !s.End.IsZero() && s.End.Before(c.from) {
+		return false
+	}
+
+	return true
+}
+
+func (c *config) inRange(line []byte) bool {
+	if c.timestamp == nil {
+		return true
+	}
+
+	t, ok := c.timestamp(line)
+	if !ok {
+		return true
+	}
+
+	return (c.from.IsZero() || !t.Before(c.from)) && (c.to.IsZero() || t.Before(c.to))
+}
+
+// lines returns an iterator over the lines read from r for which
+// keep returns true.
+func lines(r io.Reader, keep func(line []byte) bool) iter.Seq2[[]byte, error] {
+	return func(yield func([]byte, error) bool) {
+		br := bufio.NewReader(r)
+
+		var line []byte
+		for {
+			chunk, err := br.ReadSlice('\n')
+			line = append(line, chunk...)
+
+			if errors.Is(err, bufio.ErrBufferFull) {
+				continue
+			}
+
+			if len(line) > 0 && keep(line) {
+				if !yield(line, nil) {
+					return
+				}
+			}
+
+			line = line[:0]
+
+			if errors.Is(err, io.EOF) {
+				return
+			}
+
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+		}
+	}
+}
+
+// PrefixTimestamp returns a function for WithTimestamp parsing the
+// timestamp at the start of a line with the given layout. The
+// timestamp spans as many space-separated fields as the layout does,
+// so layouts of variable width such as time.RFC3339 are supported.
+func PrefixTimestamp(layout string) func(line []byte) (time.Time, bool) {
+	fields := strings.Count(layout, " ") + 1
+
+	return func(line []byte) (time.Time, bool) {
+		end := 0
+		for i := 0; i < fields; i++ {
+			if i > 0 {
+				end++
+			}
+
+			j := bytes.IndexAny(line[end:], " \n")
+			if j < 0 {
+				end = len(line)
+				break
+			}
+
+			end += j
+		}
+
+		if end > len(line) {
+			return time.Time{}, false
+		}
+
+		t, err := time.Parse(layout, string(line[:end]))
+		return t, err == nil
+	}
+}
+
+// JSONTimestamp returns a function for WithTimestamp parsing the
+// string value of the given top-level field of a JSON line, such as
+// the "time" field written by zerolog or slog, with the given layout.
+func JSONTimestamp(field, layout string) func(line []byte) (time.Time, bool) {
+	key := []byte(`"` + field + `":"`)
+
+	return func(line []byte) (time.Time, bool) {
+		i := bytes.Index(line, key)
+		if i < 0 {
+			return time.Time{}, false
+		}
+
+		value := line[i+len(key):]
+
+		j := bytes.IndexByte(value, '"')
+		if j < 0 {
+			return time.Time{}, false
+		}
+
+		t, err := time.Parse(layout, string(value[:j]))
+		return t, err == nil
+	}
+}
@@ -0,0 +1,142 @@
+package reader
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	filewriter "github.com/mtchuikov/file-writer"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func writeSegments(t *testing.T, fs afero.Fs, segments ...[]string) {
+	fw, err := filewriter.New(
+		"test.log",
+		filewriter.WithFileSystem(fs),
+		filewriter.WithLogFlushInterval(0),
+	)
+	require.NoError(t, err, "expected no error when creating file writer, got '%v'", err)
+
+	for i, lines := range segments {
+		if i > 0 {
+			err = fw.Rotate()
+			require.NoError(t, err, "expected no error when rotating, got '%v'", err)
+		}
+
+		for _, line := range lines {
+			_, err = fw.Write([]byte(line))
+			require.NoError(t, err, "expected no error when writing, got '%v'", err)
+		}
+	}
+
+	err = fw.Close()
+	require.NoError(t, err, "expected no error when closing, got '%v'", err)
+}
+
+func TestReaderRead(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	writeSegments(t, fs, []string{"one\n", "two\n"}, []string{"three\n"}, []string{"four\n"})
+
+	r, err := Open("test.log", WithOptions(filewriter.WithFileSystem(fs)))
+	require.NoError(t, err, "expected no error when opening reader, got '%v'", err)
+	defer r.Close()
+
+	segments := r.Segments()
+	require.Len(t, segments, 3, "unexpected number of segments")
+	require.NotNil(t, segments[0].Codec, "expected backup to be compressed")
+	require.True(t, segments[2].Live, "expected last segment to be the live file")
+
+	data, err := io.ReadAll(r)
+	require.NoError(t, err, "expected no error when reading, got '%v'", err)
+	require.Equal(t, "one\ntwo\nthree\nfour\n", string(data), "unexpected log content")
+}
+
+func TestReaderLines(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	stamp := func(d time.Duration, msg string) string {
+		return now.Add(d).Format(time.RFC3339) + " " + msg + "\n"
+	}
+
+	fs := afero.NewMemMapFs()
+	writeSegments(t, fs,
+		[]string{stamp(-2*time.Hour, "a"), stamp(-time.Hour, "b")},
+		[]string{stamp(time.Hour, "c"), "  continued\n", stamp(2*time.Hour, "d")},
+	)
+
+	r, err := Open("test.log",
+		WithOptions(filewriter.WithFileSystem(fs)),
+		WithTimeRange(now.Add(-90*time.Minute), now.Add(90*time.Minute)),
+		WithTimestamp(PrefixTimestamp(time.RFC3339)),
+	)
+	require.NoError(t, err, "expected no error when opening reader, got '%v'", err)
+	defer r.Close()
+
+	var lines []string
+	for line, err := range r.Lines() {
+		require.NoError(t, err, "expected no error when iterating, got '%v'", err)
+		lines = append(lines, string(line))
+	}
+
+	expected := []string{stamp(-time.Hour, "b"), stamp(time.Hour, "c"), "  continued\n"}
+	require.Equal(t, expected, lines, "unexpected lines")
+}
+
+func TestJSONTimestamp(t *testing.T) {
+	fn := JSONTimestamp("time", time.RFC3339)
+
+	ts, ok := fn([]byte(`{"level":"info","time":"2024-01-02T03:04:05Z","msg":"hi"}` + "\n"))
+	require.True(t, ok, "expected timestamp to be found")
+	require.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), ts, "unexpected timestamp")
+
+	_, ok = fn([]byte(`{"msg":"hi"}` + "\n"))
+	require.False(t, ok, "expected no timestamp")
+}
+
+func TestPrefixTimestamp(t *testing.T) {
+	fn := PrefixTimestamp(time.DateTime)
+
+	ts, ok := fn([]byte("2024-01-02 03:04:05 INFO hi\n"))
+	require.True(t, ok, "expected timestamp to be found")
+	require.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), ts, "unexpected timestamp")
+
+	_, ok = fn([]byte("  continued\n"))
+	require.False(t, ok, "expected no timestamp")
+}
+
+func TestReaderRecords(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	fw, err := filewriter.New(
+		"test.log",
+		filewriter.WithFileSystem(fs),
+		filewriter.WithFraming(true),
+		filewriter.WithLogFlushInterval(0),
+	)
+	require.NoError(t, err, "expected no error when creating file writer, got '%v'", err)
+
+	for i, record := range []string{"one", "two\nlines", "three"} {
+		if i == 2 {
+			err = fw.Rotate()
+			require.NoError(t, err, "expected no error when rotating, got '%v'", err)
+		}
+
+		_, err = fw.Write([]byte(record))
+		require.NoError(t, err, "expected no error when writing, got '%v'", err)
+	}
+
+	err = fw.Close()
+	require.NoError(t, err, "expected no error when closing, got '%v'", err)
+
+	r, err := Open("test.log", WithOptions(filewriter.WithFileSystem(fs)))
+	require.NoError(t, err, "expected no error when opening reader, got '%v'", err)
+	defer r.Close()
+
+	var records []string
+	for record, err := range r.Records() {
+		require.NoError(t, err, "expected no error when iterating, got '%v'", err)
+		records = append(records, string(record))
+	}
+
+	require.Equal(t, []string{"one", "two\nlines", "three"}, records, "unexpected records")
+}
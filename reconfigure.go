@@ -0,0 +1,235 @@
+package filewriter
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Apply replaces the settings of the running FileWriter with c. The
+// settings are swapped under the lock, so no write sees a mix of the
+// old and the new ones. The flush ticker is restarted if the
+// FlushInterval changed, the background compressor if the compression
+// settings did, and the backups are pruned right away if a retention
+// limit changed. The buffer is flushed before it is resized.
+//
+// The filesystem, the flags, framing and the settings of the
+// asynchronous mode can't be changed on a running FileWriter; Apply
+// returns a *ConfigError if c changes them, as it does if c is
+// invalid, leaving the settings untouched.
+func (fw *FileWriter) Apply(c Config) error {
+	err := c.Validate()
+	if err != nil {
+		return err
+	}
+
+	fw.mu.Lock()
+	old, pending, err := fw.applyLocked(c)
+	fw.mu.Unlock()
+
+	// The compressions queued to a replaced compressor, and the
+	// pending ones if compression was turned off, are waited for
+	// without the lock, so writes don't stall behind them.
+	if old != nil {
+		old.stopContext(context.Background(), true, pending)
+	}
+
+	return err
+}
+
+// Update applies the options to the current settings of the running
+// FileWriter, like Apply does with a whole Config.
+func (fw *FileWriter) Update(opts ...Option) error {
+	fw.mu.Lock()
+
+	c, err := applyOptions(fw.Config, opts...)
+	if err != nil {
+		fw.mu.Unlock()
+		return err
+	}
+
+	old, pending, err := fw.applyLocked(c)
+	fw.mu.Unlock()
+
+	if old != nil {
+		old.stopContext(context.Background(), true, pending)
+	}
+
+	return err
+}
+
+// applyLocked swaps the settings for c, which must be valid, and
+// returns the compressor it replaced, if any, with the pending jobs
+// left for it to finish. It must be called with fw.mu held.
+func (fw *FileWriter) applyLocked(c Config) (*compressor, []compressJob, error) {
+	if fw.File == nil {
+		return nil, nil, newError(OpUpdate, "", ErrClosed)
+	}
+
+	var errs []error
+	fixed := func(setting string, changed bool) {
+		if changed {
+			errs = append(errs, &SettingError{Setting: setting, Reason: "can't be changed on a running file writer"})
+		}
+	}
+
+	fixed("Fs", !sameValue(c.Fs, fw.Fs))
+	fixed("Flags", c.Flags != fw.Flags)
+	fixed("Framed", c.Framed != fw.Framed)
+	fixed("AsyncQueueSize", c.AsyncQueueSize != fw.AsyncQueueSize)
+	fixed("OverflowPolicy", c.OverflowPolicy != fw.OverflowPolicy)
+	fixed("SpillWriter", !sameValue(c.SpillWriter, fw.SpillWriter))
+
+	if len(errs) > 0 {
+		return nil, nil, &ConfigError{Errs: errs}
+	}
+
+	if c.BufSize != fw.BufSize {
+		err := fw.flushBuf()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		fw.Buf = NewBuffer(fw.Wc, c.BufSize)
+	}
+
+	prev := fw.Config
+	fw.Config = c
+
+	if c.FlushInterval != prev.FlushInterval {
+		fw.restartTicker()
+	}
+
+	var old *compressor
+	var pending []compressJob
+	if c.Compress != prev.Compress ||
+		c.CompressWorkers != prev.CompressWorkers ||
+		c.CompressQueueSize != prev.CompressQueueSize {
+		old, fw.compressor = fw.compressor, nil
+		fw.runCompressor()
+		fw.queuePendingCompress()
+
+		// Without compression nothing would drain the pending jobs,
+		// so the replaced compressor finishes them.
+		if fw.compressor == nil {
+			pending, fw.pendingCompress = fw.pendingCompress, nil
+		}
+	}
+
+	if c.MaxBackups != prev.MaxBackups ||
+		c.MaxAge != prev.MaxAge ||
+		c.MaxTotalSize != prev.MaxTotalSize {
+		fw.schedulePrune(fw.retention())
+	}
+
+	return old, pending, nil
+}
+
+// sameValue reports whether a and b are equal, treating values that
+// can't be compared as different.
+func sameValue(a, b any) bool {
+	ta, tb := reflect.TypeOf(a), reflect.TypeOf(b)
+	if ta != tb {
+		return false
+	}
+
+	if ta != nil && !ta.Comparable() {
+		return false
+	}
+
+	return a == b
+}
+
+// handleError reports err through the ErrorHandler from outside of
+// the lock.
+func (fw *FileWriter) handleError(err error) {
+	fw.mu.Lock()
+	handler := fw.ErrorHandler
+	fw.mu.Unlock()
+
+	handler(fw, err)
+}
+
+// WatchConfig applies the configuration file at path whenever it
+// changes, checking its modification time and size every interval.
+// The file is applied on top of the settings the FileWriter had when
+// WatchConfig was called, followed by the options, e.g. WithEnv, so
+// a setting removed from the file reverts to its previous value.
+// The file is also applied on the first check. Errors, including
+// invalid settings, are reported through the ErrorHandler and leave
+// the settings untouched. Watching stops when the FileWriter is
+// closed or the returned function is called. A *ConfigError is
+// returned if interval isn't positive.
+func (fw *FileWriter) WatchConfig(path string, interval time.Duration, opts ...Option) (stop func(), err error) {
+	if interval <= 0 {
+		return nil, &ConfigError{Errs: []error{
+			&SettingError{Setting: "interval", Value: interval, Reason: "must be positive"},
+		}}
+	}
+
+	fw.mu.Lock()
+	base := fw.Config
+	closed := fw.Done
+	fw.mu.Unlock()
+
+	opts = append([]Option{WithConfigFile(path)}, opts...)
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	var modTime time.Time
+	var size int64 = -1
+
+	go func() {
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-closed:
+				return
+			case <-ticker.C:
+			}
+
+			stat, err := os.Stat(path)
+			if err != nil {
+				// The file is briefly missing while an editor or a
+				// deployment tool replaces it, which isn't an error.
+				if !os.IsNotExist(err) {
+					fw.handleError(newError(OpLoad, path, err))
+				}
+				continue
+			}
+
+			if stat.ModTime().Equal(modTime) && stat.Size() == size {
+				continue
+			}
+
+			modTime, size = stat.ModTime(), stat.Size()
+
+			c, err := applyOptions(base, opts...)
+			if err == nil {
+				err = fw.Apply(c)
+			}
+
+			// The ticker may fire along with the close of the
+			// FileWriter, which isn't an error.
+			if errors.Is(err, ErrClosed) {
+				return
+			}
+
+			if err != nil {
+				fw.handleError(err)
+			}
+		}
+	}()
+
+	var once sync.Once
+
+	return func() {
+		once.Do(func() { close(done) })
+	}, nil
+}
@@ -0,0 +1,216 @@
+package filewriter
+
+import (
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdate(t *testing.T) {
+	afs := &afero.Afero{Fs: afero.NewMemMapFs()}
+	payload := []byte("Hello, world!\n")
+
+	fw, err := New("test.log", WithFileSystem(afs), WithLogFlushInterval(0))
+	require.NoError(t, err, "expected no error when creating file writer, got '%v'", err)
+	defer fw.Close()
+
+	_, err = fw.Write(payload)
+	require.NoError(t, err, "expected no error when writing, got '%v'", err)
+
+	err = fw.Update(WithFileMaxSize(64), WithLogFlushInterval(10*time.Millisecond))
+	require.NoError(t, err, "expected no error when updating, got '%v'", err)
+
+	fw.mu.Lock()
+	maxSize := fw.MaxSize
+	fw.mu.Unlock()
+	require.Equal(t, uint(64*1024*1024), maxSize, "unexpected max size")
+
+	require.Eventually(t, func() bool {
+		content, _ := afs.ReadFile("test.log")
+		return string(content) == string(payload)
+	}, time.Second, 10*time.Millisecond, "expected the restarted ticker to flush the buffer")
+}
+
+func TestUpdateInvalid(t *testing.T) {
+	fw, err := New("test.log", WithFileSystem(afero.NewMemMapFs()), WithLogFlushInterval(0))
+	require.NoError(t, err, "expected no error when creating file writer, got '%v'", err)
+	defer fw.Close()
+
+	err = fw.Update(WithFileMaxBackups(3), WithFileMaxSize(-1))
+	require.ErrorIs(t, err, ErrInvalidConfig, "expected a config error, got '%v'", err)
+
+	err = fw.Update(WithFraming(true))
+	require.ErrorIs(t, err, ErrInvalidConfig, "expected a config error, got '%v'", err)
+	require.ErrorContains(t, err, "Framed", "expected the fixed setting to be named")
+
+	require.Equal(t, defaultFileMaxBackups, fw.MaxBackups, "expected the settings to be untouched")
+	require.False(t, fw.Framed, "expected the settings to be untouched")
+}
+
+func TestApplyClosed(t *testing.T) {
+	fw, err := New("test.log", WithFileSystem(afero.NewMemMapFs()), WithLogFlushInterval(0))
+	require.NoError(t, err, "expected no error when creating file writer, got '%v'", err)
+
+	c := fw.Config
+
+	err = fw.Close()
+	require.NoError(t, err, "expected no error when closing, got '%v'", err)
+
+	err = fw.Apply(c)
+	require.ErrorIs(t, err, ErrClosed, "expected a closed error, got '%v'", err)
+}
+
+func TestUpdateConcurrentWrites(t *testing.T) {
+	fw, err := New("test.log", WithFileSystem(afero.NewMemMapFs()), WithLogFlushInterval(0))
+	require.NoError(t, err, "expected no error when creating file writer, got '%v'", err)
+	defer fw.Close()
+
+	var wg sync.WaitGroup
+	var writeErr error
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		for i := 0; i < 100 && writeErr == nil; i++ {
+			_, writeErr = fw.Write([]byte("Hello, world!\n"))
+		}
+	}()
+
+	for i := 1; i <= 10; i++ {
+		err := fw.Update(WithBufferSize(i*512), WithLogFlushInterval(time.Duration(i)*time.Millisecond))
+		require.NoError(t, err, "expected no error when updating, got '%v'", err)
+	}
+
+	wg.Wait()
+	require.NoError(t, writeErr, "expected no error when writing, got '%v'", writeErr)
+}
+
+func TestWatchConfig(t *testing.T) {
+	path := writeConfigFile(t, "fw.yaml", "max_size: 64MiB\n")
+	errs := make(chan error, 1)
+
+	fw, err := New(
+		"test.log",
+		WithFileSystem(afero.NewMemMapFs()),
+		WithLogFlushInterval(0),
+		WithErrorHandler(func(fw *FileWriter, err error) {
+			select {
+			case errs <- err:
+			default:
+			}
+		}),
+	)
+	require.NoError(t, err, "expected no error when creating file writer, got '%v'", err)
+	defer fw.Close()
+
+	stop, err := fw.WatchConfig(path, 10*time.Millisecond)
+	require.NoError(t, err, "expected no error when watching config, got '%v'", err)
+	defer stop()
+
+	maxSize := func() uint {
+		fw.mu.Lock()
+		defer fw.mu.Unlock()
+		return fw.MaxSize
+	}
+
+	require.Eventually(t, func() bool {
+		return maxSize() == 64<<20
+	}, time.Second, 10*time.Millisecond, "expected the file to be applied")
+
+	err = os.WriteFile(path, []byte("max_size: 128MiB\n"), 0644)
+	require.NoError(t, err, "expected no error when writing config file, got '%v'", err)
+
+	require.Eventually(t, func() bool {
+		return maxSize() == 128<<20
+	}, time.Second, 10*time.Millisecond, "expected the change to be applied")
+
+	err = os.WriteFile(path, []byte("max_size: 64XB\n"), 0644)
+	require.NoError(t, err, "expected no error when writing config file, got '%v'", err)
+
+	select {
+	case err = <-errs:
+		require.ErrorIs(t, err, ErrInvalidConfig, "expected a config error, got '%v'", err)
+	case <-time.After(time.Second):
+		t.Fatal("expected the invalid file to be reported")
+	}
+
+	require.Equal(t, uint(128<<20), maxSize(), "expected the settings to be untouched")
+}
+
+func TestWatchConfigStop(t *testing.T) {
+	path := writeConfigFile(t, "fw.yaml", "max_size: 64MiB\n")
+	handled := make(chan error, 1)
+
+	fw, err := New(
+		"test.log",
+		WithFileSystem(afero.NewMemMapFs()),
+		WithLogFlushInterval(0),
+		WithErrorHandler(func(fw *FileWriter, err error) {
+			select {
+			case handled <- err:
+			default:
+			}
+		}),
+	)
+	require.NoError(t, err, "expected no error when creating file writer, got '%v'", err)
+
+	_, err = fw.WatchConfig(path, 0)
+	require.ErrorIs(t, err, ErrInvalidConfig, "expected a config error, got '%v'", err)
+
+	stop, err := fw.WatchConfig(path, 10*time.Millisecond)
+	require.NoError(t, err, "expected no error when watching config, got '%v'", err)
+
+	stop()
+	stop()
+
+	stop, err = fw.WatchConfig(path, 10*time.Millisecond)
+	require.NoError(t, err, "expected no error when watching config, got '%v'", err)
+	defer stop()
+
+	err = fw.Close()
+	require.NoError(t, err, "expected no error when closing, got '%v'", err)
+
+	err = os.WriteFile(path, []byte("max_size: 128MiB\n"), 0644)
+	require.NoError(t, err, "expected no error when writing config file, got '%v'", err)
+
+	select {
+	case err = <-handled:
+		t.Fatalf("expected watching to stop on close, got '%v'", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestUpdateCompressOffPending(t *testing.T) {
+	afs := &afero.Afero{Fs: afero.NewMemMapFs()}
+
+	fw, err := New("test.log", WithFileSystem(afs), WithFileCompress(true), WithLogFlushInterval(0))
+	require.NoError(t, err, "expected no error when creating file writer, got '%v'", err)
+	defer fw.Close()
+
+	err = afs.WriteFile("test.log.1", []byte("Hello, world!\n"), 0644)
+	require.NoError(t, err, "expected no error when writing backup, got '%v'", err)
+
+	// The backup waits for room in the queue of the compressor.
+	fw.mu.Lock()
+	fw.pendingCompress = append(fw.pendingCompress, compressJob{
+		fs:    afs,
+		src:   "test.log.1",
+		mode:  defaulFileMode,
+		codec: Gzip,
+	})
+	fw.mu.Unlock()
+
+	err = fw.Update(WithFileCompress(false))
+	require.NoError(t, err, "expected no error when updating, got '%v'", err)
+
+	require.Nil(t, fw.pendingCompress, "expected the pending backup to be handed over")
+
+	exists, err := afs.Exists("test.log.1.gz")
+	require.NoError(t, err, "expected no error when checking file existence, got '%v'", err)
+	require.True(t, exists, "expected the pending backup to be compressed")
+}
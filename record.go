@@ -0,0 +1,86 @@
+package filewriter
+
+import (
+	"fmt"
+)
+
+// OversizePolicy defines how Write handles a record larger than
+// MaxSize, which can't fit into a log file without overflowing it.
+type OversizePolicy int
+
+const (
+	OversizeIsolate  OversizePolicy = iota // write the record alone to a fresh file
+	OversizeReject                         // reject the record with a *RecordTooLargeError
+	OversizeTruncate                       // truncate the record to MaxSize, ending it with TruncateMarker
+)
+
+// RecordTooLargeError is returned by Write for a record larger than
+// MaxSize when the OversizePolicy is OversizeReject.
+type RecordTooLargeError struct {
+	Size    uint // the size of the rejected record (in bytes)
+	MaxSize uint // the maximum allowed size of the log file (in bytes)
+}
+
+func (e *RecordTooLargeError) Error() string {
+	return fmt.Sprintf(
+		"record of %d bytes exceeds the maximum log file size of %d bytes",
+		e.Size, e.MaxSize,
+	)
+}
+
+func (e *RecordTooLargeError) Is(target error) bool {
+	return target == ErrRecordTooLarge
+}
+
+// truncateRecord shortens p to max bytes, replacing its end with
+// marker, so that readers can tell the record was cut.
+func truncateRecord(p []byte, max uint, marker []byte) []byte {
+	if uint(len(marker)) >= max {
+		return p[:max]
+	}
+
+	cut := max - uint(len(marker))
+
+	truncated := make([]byte, 0, max)
+	truncated = append(truncated, p[:cut]...)
+	truncated = append(truncated, marker...)
+
+	return truncated
+}
+
+// rotate flushes the buffered data to the current log file and
+// rotates it. Flushing first guarantees that a record is never
+// split between two files: the buffer may hold the tail of a record
+// whose head was already flushed, which must land in the same file.
+func (fw *FileWriter) rotate() error {
+	err := fw.flushBuf()
+	if err != nil {
+		return err
+	}
+
+	fw.BatchSize = 0
+
+	return fw.rotateFile()
+}
+
+// writeIsolated writes a record larger than MaxSize to a log file
+// of its own: the current file is rotated unless it's empty, the
+// record is written and flushed, and the file is rotated again so
+// that the following records start a fresh file.
+func (fw *FileWriter) writeIsolated(p []byte) (int, error) {
+	if fw.Size+uint(fw.Buf.Buffered()) > 0 {
+		err := fw.rotate()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := fw.Buf.Write(p)
+	if err != nil {
+		return n, fw.metrics.countError(errorKindWrite, newError(OpWrite, fw.File.Name(), err))
+	}
+
+	fw.metrics.recordsWritten.Add(1)
+
+	return n, fw.rotate()
+}
@@ -0,0 +1,143 @@
+package filewriter
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/suite"
+)
+
+type testRecordSuite struct {
+	suite.Suite
+
+	afs *afero.Afero
+
+	fileName string
+	maxSize  uint
+
+	fw *FileWriter
+}
+
+func TestRecordSuite(t *testing.T) {
+	tr := &testRecordSuite{fileName: "test.log", maxSize: 64}
+	suite.Run(t, tr)
+}
+
+func (tr *testRecordSuite) SetupTest() {
+	tr.afs = &afero.Afero{Fs: afero.NewMemMapFs()}
+
+	fw, err := New(
+		tr.fileName,
+		WithFileSystem(tr.afs),
+		WithFileCompress(false),
+		WithLogFlushInterval(0),
+	)
+	tr.Require().NoError(err, "expected no error when creating file writer, got '%v'", err)
+
+	fw.MaxSize = tr.maxSize
+	tr.fw = fw
+}
+
+func (tr *testRecordSuite) TearDownTest() {
+	tr.fw.Close()
+}
+
+// readFiles returns the content of the backups from the oldest to
+// the newest one, followed by the content of the live log file.
+func (tr *testRecordSuite) readFiles() []string {
+	backups, err := listBackups(tr.afs, tr.fileName, tr.fw.RotatePostfix)
+	tr.Require().NoError(err, "expected no error when listing backups, got '%v'", err)
+
+	var files []string
+	for i := len(backups) - 1; i >= 0; i-- {
+		content, err := tr.afs.ReadFile(backups[i].Path)
+		tr.Require().NoError(err, "expected no error when reading backup, got '%v'", err)
+
+		files = append(files, string(content))
+	}
+
+	content, err := tr.afs.ReadFile(tr.fileName)
+	tr.Require().NoError(err, "expected no error when reading file, got '%v'", err)
+
+	return append(files, string(content))
+}
+
+func (tr *testRecordSuite) TestRecordBoundary() {
+	record := []byte(strings.Repeat("x", 29) + "\n")
+
+	for range 20 {
+		_, err := tr.fw.Write(record)
+		tr.Require().NoError(err, "expected no error when writing, got '%v'", err)
+	}
+
+	err := tr.fw.Close()
+	tr.Require().NoError(err, "expected no error when closing, got '%v'", err)
+
+	var total int
+	for _, content := range tr.readFiles() {
+		tr.Require().LessOrEqualf(
+			uint(len(content)), tr.maxSize,
+			"expected file to fit into max size, got %v bytes", len(content),
+		)
+
+		tr.Require().Zerof(
+			len(content)%len(record),
+			"expected file to hold whole records, got %v bytes", len(content),
+		)
+
+		total += len(content)
+	}
+
+	tr.Require().Equal(20*len(record), total, "expected every record to be written")
+}
+
+func (tr *testRecordSuite) TestOversizeReject() {
+	tr.fw.OversizePolicy = OversizeReject
+
+	record := bytes.Repeat([]byte("x"), int(tr.maxSize)+1)
+	n, err := tr.fw.Write(record)
+
+	var tooLarge *RecordTooLargeError
+	tr.Require().True(errors.As(err, &tooLarge), "expected record too large error, got '%v'", err)
+	tr.Require().Zerof(n, "expected nothing to be written, got %v bytes", n)
+}
+
+func (tr *testRecordSuite) TestOversizeTruncate() {
+	tr.fw.OversizePolicy = OversizeTruncate
+
+	record := bytes.Repeat([]byte("x"), int(tr.maxSize)*2)
+	n, err := tr.fw.Write(record)
+
+	tr.Require().NoError(err, "expected no error when writing, got '%v'", err)
+	tr.Require().Equal(len(record), n, "expected the whole record to be reported as written")
+
+	err = tr.fw.Close()
+	tr.Require().NoError(err, "expected no error when closing, got '%v'", err)
+
+	content := tr.readFiles()[0]
+
+	tr.Require().Len(content, int(tr.maxSize), "expected record to be truncated to max size")
+	tr.Require().True(
+		strings.HasSuffix(content, defaultTruncateMarker),
+		"expected truncated record to end with the marker, got '%v'", content,
+	)
+}
+
+func (tr *testRecordSuite) TestOversizeIsolate() {
+	small := []byte("Hello, world!\n")
+	large := bytes.Repeat([]byte("x"), int(tr.maxSize)*2)
+
+	for _, record := range [][]byte{small, large, small} {
+		_, err := tr.fw.Write(record)
+		tr.Require().NoError(err, "expected no error when writing, got '%v'", err)
+	}
+
+	err := tr.fw.Close()
+	tr.Require().NoError(err, "expected no error when closing, got '%v'", err)
+
+	expected := []string{string(small), string(large), string(small)}
+	tr.Require().Equal(expected, tr.readFiles(), "expected oversized record in a file of its own")
+}
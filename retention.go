@@ -0,0 +1,270 @@
+package filewriter
+
+import (
+	"errors"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// backup describes a rotated log file found next to the live one.
+type backup struct {
+	Path string
+	Time time.Time // the rotation time parsed from the postfix
+	Seq  int       // the sequence number of backups rotated at the same time
+	Size int64
+}
+
+// listBackups discovers the backups of the log file with the given
+// name. A file is considered a backup if its name consists of the
+// log file name, a dot, a timestamp formatted with postfix, an
+// optional sequence number and an optional extension of one of the
+// codecs. The result is sorted from the newest backup to the oldest
+// one.
+func listBackups(fs Fs, name, postfix string) ([]backup, error) {
+	dir, base := splitDir(name)
+
+	infos, err := readDir(fs, dir)
+	if err != nil {
+		return nil, newError(OpList, dir, err)
+	}
+
+	prefix := base + "."
+
+	var backups []backup
+	for _, info := range infos {
+		if info.IsDir() || !strings.HasPrefix(info.Name(), prefix) {
+			continue
+		}
+
+		stamp := strings.TrimPrefix(info.Name(), prefix)
+		if codec, ok := CodecByExt(stamp); ok {
+			stamp = strings.TrimSuffix(stamp, codec.Ext())
+		}
+
+		t, seq, ok := parseBackupStamp(stamp, postfix)
+		if !ok {
+			continue
+		}
+
+		backups = append(backups, backup{
+			Path: filepath.Join(dir, info.Name()),
+			Time: t,
+			Seq:  seq,
+			Size: info.Size(),
+		})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		if backups[i].Time.Equal(backups[j].Time) {
+			return backups[i].Seq > backups[j].Seq
+		}
+
+		return backups[i].Time.After(backups[j].Time)
+	})
+
+	return backups, nil
+}
+
+// withoutPartial drops the compressed twins of uncompressed
+// backups. A backup waiting for background compression exists both
+// uncompressed and, partially, compressed; only the uncompressed
+// file is complete, and the compressed one must be left alone until
+// compression replaces the uncompressed one.
+func withoutPartial(backups []backup) []backup {
+	uncompressed := make(map[string]bool)
+	for _, b := range backups {
+		if _, ok := CodecByExt(b.Path); !ok {
+			uncompressed[b.Path] = true
+		}
+	}
+
+	complete := backups[:0:0]
+	for _, b := range backups {
+		codec, ok := CodecByExt(b.Path)
+		if ok && uncompressed[strings.TrimSuffix(b.Path, codec.Ext())] {
+			continue
+		}
+
+		complete = append(complete, b)
+	}
+
+	return complete
+}
+
+// parseBackupStamp parses the timestamp of a backup, followed by
+// an optional sequence number appended by backupName. Timestamps
+// without a zone are in local time, as backupName formats them. The
+// sequence number is split off first, since time.Parse accepts it as
+// fractional seconds after a postfix ending in seconds.
+func parseBackupStamp(stamp, postfix string) (time.Time, int, bool) {
+	i := strings.LastIndexByte(stamp, '.')
+	if i >= 0 {
+		seq, err := strconv.Atoi(stamp[i+1:])
+		if err == nil && seq > 0 {
+			t, err := time.ParseInLocation(postfix, stamp[:i], time.Local)
+			if err == nil {
+				return t, seq, true
+			}
+		}
+	}
+
+	t, err := time.ParseInLocation(postfix, stamp, time.Local)
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+
+	return t, 0, true
+}
+
+// retention holds the limits applied to rotated backups. It is
+// copied out of the FileWriter when pruning is scheduled, so that
+// the background pruning never reads the writer's fields.
+type retention struct {
+	fs           Fs
+	name         string
+	postfix      string
+	maxBackups   int
+	maxAge       time.Duration
+	maxTotalSize uint
+	onPruned     []func(PruneEvent) error
+}
+
+func (r retention) enabled() bool {
+	return r.maxBackups > 0 || r.maxAge > 0 || r.maxTotalSize > 0
+}
+
+// expired returns the backups that exceed any of the retention
+// limits. The backups must be sorted from the newest to the oldest.
+func (r retention) expired(backups []backup, now time.Time) []backup {
+	var (
+		expired   []backup
+		totalSize uint
+	)
+
+	for i, b := range backups {
+		totalSize += uint(b.Size)
+
+		switch {
+		case r.maxBackups > 0 && i >= r.maxBackups:
+		case r.maxAge > 0 && now.Sub(b.Time) > r.maxAge:
+		case r.maxTotalSize > 0 && totalSize > r.maxTotalSize:
+		default:
+			continue
+		}
+
+		expired = append(expired, b)
+	}
+
+	return expired
+}
+
+// prune removes the expired backups and returns the paths of the
+// removed ones.
+func (r retention) prune() ([]string, error) {
+	backups, err := listBackups(r.fs, r.name, r.postfix)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		removed []string
+		errs    []error
+	)
+
+	for _, b := range r.expired(withoutPartial(backups), currentTime()) {
+		err = r.fs.Remove(b.Path)
+		if err != nil {
+			errs = append(errs, newError(OpRemove, b.Path, err))
+			continue
+		}
+
+		removed = append(removed, b.Path)
+	}
+
+	return removed, errors.Join(errs...)
+}
+
+// retention returns a snapshot of the retention limits of the
+// log file currently open. Nothing is pruned when old log files
+// are deleted on rotation.
+func (fw *FileWriter) retention() retention {
+	if fw.DeleteOld {
+		return retention{}
+	}
+
+	return retention{
+		fs:           fw.Fs,
+		name:         fw.File.Name(),
+		postfix:      fw.RotatePostfix,
+		maxBackups:   fw.MaxBackups,
+		maxAge:       fw.MaxAge,
+		maxTotalSize: fw.MaxTotalSize,
+		onPruned:     fw.OnPruned,
+	}
+}
+
+// prune removes the backups that exceed the retention limits,
+// reports failures through the given error handler and runs the
+// OnPruned hooks if anything was removed or failed. Concurrent
+// prunes are serialized by pruneMu. It must be called without
+// holding fw.mu.
+func (fw *FileWriter) prune(r retention, errorHandler func(fw *FileWriter, err error)) {
+	if !r.enabled() {
+		return
+	}
+
+	fw.pruneMu.Lock()
+	removed, err := r.prune()
+	fw.pruneMu.Unlock()
+
+	if err != nil {
+		errorHandler(fw, fw.metrics.countError(errorKindPrune, err))
+	}
+
+	if len(removed) > 0 || err != nil {
+		event := PruneEvent{Path: r.name, Removed: removed, Err: err}
+		queueHooks(fw, r.onPruned, event, errorHandler)
+		fw.runHooks()
+	}
+}
+
+// schedulePrune runs pruning in its own goroutine to keep it off
+// the write path.
+func (fw *FileWriter) schedulePrune(r retention) {
+	if !r.enabled() {
+		return
+	}
+
+	errorHandler := fw.ErrorHandler
+	go fw.prune(r, errorHandler)
+}
+
+// Prune removes the backups of the log file with the given name that
+// exceed the retention limits set by the options, the same way a
+// FileWriter does after rotation, and returns the paths of the
+// removed backups. It is meant for pruning offline, e.g. from a
+// maintenance script.
+func Prune(name string, opts ...Option) ([]string, error) {
+	c, err := NewConfig(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	r := retention{
+		fs:           c.Fs,
+		name:         name,
+		postfix:      c.RotatePostfix,
+		maxBackups:   c.MaxBackups,
+		maxAge:       c.MaxAge,
+		maxTotalSize: c.MaxTotalSize,
+	}
+
+	if !r.enabled() {
+		return nil, nil
+	}
+
+	return r.prune()
+}
@@ -0,0 +1,151 @@
+package filewriter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetentionExpired(t *testing.T) {
+	now := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+
+	backups := []backup{
+		{Path: "a", Time: now.Add(-1 * time.Hour), Size: 10},
+		{Path: "b", Time: now.Add(-2 * time.Hour), Size: 10},
+		{Path: "c", Time: now.Add(-3 * time.Hour), Size: 10},
+		{Path: "d", Time: now.Add(-4 * time.Hour), Size: 10},
+	}
+
+	tests := []struct {
+		name     string
+		r        retention
+		expected []string
+	}{
+		{name: "unlimited", r: retention{}},
+		{name: "max backups", r: retention{maxBackups: 2}, expected: []string{"c", "d"}},
+		{name: "max age", r: retention{maxAge: 150 * time.Minute}, expected: []string{"c", "d"}},
+		{name: "max total size", r: retention{maxTotalSize: 25}, expected: []string{"c", "d"}},
+		{
+			name:     "combined",
+			r:        retention{maxBackups: 3, maxTotalSize: 15},
+			expected: []string{"b", "c", "d"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var paths []string
+			for _, b := range tt.r.expired(backups, now) {
+				paths = append(paths, b.Path)
+			}
+
+			require.Equal(t, tt.expected, paths, "unexpected expired backups")
+		})
+	}
+}
+
+func TestRetentionPrune(t *testing.T) {
+	afs := &afero.Afero{Fs: afero.NewMemMapFs()}
+
+	name := "logs/test.log"
+	now := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	currentTime = func() time.Time { return now }
+	t.Cleanup(func() { currentTime = time.Now })
+
+	files := []string{
+		name,
+		name + "." + now.Add(-1*time.Hour).Format(time.RFC3339) + ".gz",
+		name + "." + now.Add(-2*time.Hour).Format(time.RFC3339),
+		name + "." + now.Add(-3*time.Hour).Format(time.RFC3339) + ".gz",
+		"logs/other.log." + now.Add(-4*time.Hour).Format(time.RFC3339),
+	}
+
+	for _, f := range files {
+		err := afs.WriteFile(f, []byte("Hello, world!\n"), defaulFileMode)
+		require.NoError(t, err, "expected no error when writing file, got '%v'", err)
+	}
+
+	r := retention{fs: afs, name: name, postfix: time.RFC3339, maxBackups: 1}
+	_, err := r.prune()
+	require.NoError(t, err, "expected no error when pruning, got '%v'", err)
+
+	expected := map[string]bool{
+		files[0]: true,
+		files[1]: true,
+		files[2]: false,
+		files[3]: false,
+		files[4]: true,
+	}
+
+	for f, keep := range expected {
+		exists, err := afs.Exists(f)
+		require.NoError(t, err, "expected no error when checking file existence, got '%v'", err)
+		require.Equal(t, keep, exists, "unexpected existence of '%v'", f)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	afs := &afero.Afero{Fs: afero.NewMemMapFs()}
+
+	name := "test.log"
+	now := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+
+	files := []string{
+		name + "." + now.Add(-1*time.Hour).Format(time.RFC3339) + ".gz",
+		name + "." + now.Add(-2*time.Hour).Format(time.RFC3339) + ".gz",
+	}
+
+	for _, f := range files {
+		err := afs.WriteFile(f, []byte("Hello, world!\n"), defaulFileMode)
+		require.NoError(t, err, "expected no error when writing file, got '%v'", err)
+	}
+
+	removed, err := Prune(name, WithFileSystem(afs), WithFileMaxBackups(1))
+	require.NoError(t, err, "expected no error when pruning, got '%v'", err)
+	require.Equal(t, files[1:], removed, "unexpected removed backups")
+}
+
+func TestPruneCompressing(t *testing.T) {
+	afs := &afero.Afero{Fs: afero.NewMemMapFs()}
+
+	name := "test.log"
+	now := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+
+	// The newest backup is being compressed, so it exists both
+	// uncompressed and partially compressed.
+	compressing := name + "." + now.Add(-1*time.Hour).Format(time.RFC3339)
+	files := []string{
+		compressing,
+		compressing + ".gz",
+		name + "." + now.Add(-2*time.Hour).Format(time.RFC3339) + ".gz",
+	}
+
+	for _, f := range files {
+		err := afs.WriteFile(f, []byte("Hello, world!\n"), defaulFileMode)
+		require.NoError(t, err, "expected no error when writing file, got '%v'", err)
+	}
+
+	removed, err := Prune(name, WithFileSystem(afs), WithFileMaxBackups(2))
+	require.NoError(t, err, "expected no error when pruning, got '%v'", err)
+	require.Empty(t, removed, "expected the backup being compressed to be counted once")
+
+	removed, err = Prune(name, WithFileSystem(afs), WithFileMaxBackups(1))
+	require.NoError(t, err, "expected no error when pruning, got '%v'", err)
+	require.Equal(t, files[2:], removed, "expected only the older backup to be removed")
+}
+
+func TestParseBackupStampLocal(t *testing.T) {
+	local := time.Local
+	time.Local = time.FixedZone("UTC+3", 3*60*60)
+	t.Cleanup(func() { time.Local = local })
+
+	postfix := "2006-01-02T15-04-05"
+	now := time.Date(2024, 1, 10, 12, 0, 0, 0, time.Local)
+
+	stamp, seq, ok := parseBackupStamp(now.Format(postfix)+".2", postfix)
+	require.True(t, ok, "expected the stamp to be parsed")
+	require.Equal(t, 2, seq, "unexpected sequence number")
+	require.True(t, stamp.Equal(now), "expected %v to be parsed as local time, got %v", now, stamp)
+}
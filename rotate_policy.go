@@ -0,0 +1,136 @@
+package filewriter
+
+import "time"
+
+// RotateState describes the current log file at the moment a
+// rotation decision is made. It is passed to RotatePolicy so that
+// size and time triggers can be evaluated against the same data.
+type RotateState struct {
+	Size     uint      // the size of the log file on disk (in bytes)
+	Buffered uint      // the number of bytes waiting in the buffer
+	Pending  uint      // the size of the record about to be written
+	MaxSize  uint      // the maximum allowed size of the log file
+	Opened   time.Time // the time the current log file was started
+	Now      time.Time // the current time
+}
+
+// RotatePolicy decides whether the current log file should be
+// rotated. Policies can be combined with AnyPolicy, so that, for
+// example, a file is rotated either at midnight or once it grows
+// past MaxSize, whichever happens first.
+type RotatePolicy interface {
+	ShouldRotate(s RotateState) bool
+}
+
+// SizePolicy rotates the log file once the file size, the buffered
+// data and the pending record together reach MaxSize. It is the
+// default policy of the FileWriter.
+type SizePolicy struct{}
+
+func (SizePolicy) ShouldRotate(s RotateState) bool {
+	return s.Size+s.Buffered+s.Pending >= s.MaxSize
+}
+
+// IntervalPolicy rotates the log file once it has been open for
+// at least Interval, regardless of the wall clock.
+//
+// A non-empty file found when the FileWriter is created or reopened
+// is counted from its creation time. On platforms that don't report
+// it, such as Linux, it is counted from its last write instead: a file
+// written shortly before a restart stays open for up to Interval
+// after the restart, longer than Interval in total.
+type IntervalPolicy struct {
+	Interval time.Duration
+}
+
+func (p IntervalPolicy) ShouldRotate(s RotateState) bool {
+	if p.Interval <= 0 {
+		return false
+	}
+
+	return s.Now.Sub(s.Opened) >= p.Interval
+}
+
+// Boundary is a wall-clock boundary at which BoundaryPolicy rotates
+// the log file.
+type Boundary int
+
+const (
+	BoundaryHour Boundary = iota // the top of every hour
+	BoundaryDay                  // local midnight
+)
+
+// BoundaryPolicy rotates the log file when the wall clock crosses
+// an hour or a day boundary in the given Location. If Location is
+// nil, time.Local is used. Boundaries are computed in the local
+// time of the location, so daily rotation happens at midnight
+// even on days that are 23 or 25 hours long due to DST.
+type BoundaryPolicy struct {
+	Every    Boundary
+	Location *time.Location
+}
+
+func (p BoundaryPolicy) ShouldRotate(s RotateState) bool {
+	return !s.Now.Before(p.next(s.Opened))
+}
+
+// next returns the first boundary strictly after t.
+func (p BoundaryPolicy) next(t time.Time) time.Time {
+	loc := p.Location
+	if loc == nil {
+		loc = time.Local
+	}
+
+	t = t.In(loc)
+
+	if p.Every == BoundaryDay {
+		y, m, d := t.Date()
+		return time.Date(y, m, d+1, 0, 0, 0, 0, loc)
+	}
+
+	// Truncate using absolute durations instead of time.Date, since
+	// the latter is ambiguous for the repeated hour when the clocks
+	// go back.
+	sinceHour := time.Duration(t.Minute())*time.Minute +
+		time.Duration(t.Second())*time.Second +
+		time.Duration(t.Nanosecond())
+
+	return t.Add(-sinceHour).Add(time.Hour)
+}
+
+// AnyPolicy rotates the log file as soon as any of its policies
+// asks for it.
+type AnyPolicy []RotatePolicy
+
+func (p AnyPolicy) ShouldRotate(s RotateState) bool {
+	for _, policy := range p {
+		if policy.ShouldRotate(s) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// shouldRotate reports whether the log file must be rotated before
+// pending bytes are written. An empty log file is never rotated;
+// instead its start time is moved forward, so that time-based
+// policies measure from the first record actually written to it.
+func (fw *FileWriter) shouldRotate(pending uint) bool {
+	now := currentTime()
+	buffered := uint(fw.Buf.Buffered())
+
+	if fw.Size+buffered == 0 {
+		fw.OpenedAt = now
+		return false
+	}
+
+	return fw.RotatePolicy.ShouldRotate(RotateState{
+		Size:     fw.Size,
+		Buffered: buffered,
+		Pending:  pending,
+		MaxSize:  fw.MaxSize,
+		Opened:   fw.OpenedAt,
+		Now:      now,
+	})
+}
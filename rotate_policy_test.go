@@ -0,0 +1,102 @@
+package filewriter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSizePolicy(t *testing.T) {
+	p := SizePolicy{}
+
+	s := RotateState{Size: 10, Buffered: 5, Pending: 4, MaxSize: 20}
+	require.False(t, p.ShouldRotate(s), "expected no rotation below max size")
+
+	s.Pending = 5
+	require.True(t, p.ShouldRotate(s), "expected rotation at max size")
+}
+
+func TestIntervalPolicy(t *testing.T) {
+	p := IntervalPolicy{Interval: time.Hour}
+
+	opened := time.Date(2024, 1, 1, 10, 30, 0, 0, time.UTC)
+	s := RotateState{Opened: opened, Now: opened.Add(59 * time.Minute)}
+	require.False(t, p.ShouldRotate(s), "expected no rotation before interval")
+
+	s.Now = opened.Add(time.Hour)
+	require.True(t, p.ShouldRotate(s), "expected rotation after interval")
+}
+
+func TestBoundaryPolicy(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone database is not available: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		every  Boundary
+		opened time.Time
+		before time.Time
+		after  time.Time
+	}{
+		{
+			name:   "hourly",
+			every:  BoundaryHour,
+			opened: time.Date(2024, 6, 1, 10, 15, 0, 0, loc),
+			before: time.Date(2024, 6, 1, 10, 59, 59, 0, loc),
+			after:  time.Date(2024, 6, 1, 11, 0, 0, 0, loc),
+		},
+		{
+			name:   "daily",
+			every:  BoundaryDay,
+			opened: time.Date(2024, 6, 1, 10, 15, 0, 0, loc),
+			before: time.Date(2024, 6, 1, 23, 59, 59, 0, loc),
+			after:  time.Date(2024, 6, 2, 0, 0, 0, 0, loc),
+		},
+		{
+			// 2024-03-10 is 23 hours long in New York.
+			name:   "daily across spring forward",
+			every:  BoundaryDay,
+			opened: time.Date(2024, 3, 10, 0, 30, 0, 0, loc),
+			before: time.Date(2024, 3, 10, 23, 59, 59, 0, loc),
+			after:  time.Date(2024, 3, 11, 0, 0, 0, 0, loc),
+		},
+		{
+			// At 2024-11-03 01:00 EDT the clocks go back to 01:00 EST,
+			// so the hour boundary is one absolute hour later.
+			name:   "hourly across fall back",
+			every:  BoundaryHour,
+			opened: time.Date(2024, 11, 3, 5, 30, 0, 0, time.UTC),
+			before: time.Date(2024, 11, 3, 5, 59, 59, 0, time.UTC),
+			after:  time.Date(2024, 11, 3, 6, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := BoundaryPolicy{Every: tt.every, Location: loc}
+
+			s := RotateState{Opened: tt.opened, Now: tt.before}
+			require.False(t, p.ShouldRotate(s), "expected no rotation at %v", tt.before)
+
+			s.Now = tt.after
+			require.True(t, p.ShouldRotate(s), "expected rotation at %v", tt.after)
+		})
+	}
+}
+
+func TestAnyPolicy(t *testing.T) {
+	p := AnyPolicy{SizePolicy{}, IntervalPolicy{Interval: time.Hour}}
+
+	opened := time.Now()
+	s := RotateState{Size: 1, MaxSize: 10, Opened: opened, Now: opened}
+	require.False(t, p.ShouldRotate(s), "expected no rotation")
+
+	s.Now = opened.Add(time.Hour)
+	require.True(t, p.ShouldRotate(s), "expected rotation by interval")
+
+	s.Now, s.Size = opened, 10
+	require.True(t, p.ShouldRotate(s), "expected rotation by size")
+}
@@ -0,0 +1,136 @@
+package filewriter
+
+import (
+	"errors"
+	"io"
+	"os"
+	"time"
+)
+
+// Segment is one of the files making up a log: either a backup
+// produced by rotation or the live log file.
+type Segment struct {
+	Path  string
+	Start time.Time // the time the segment was started, zero if unknown
+	End   time.Time // the time the segment was rotated, zero for the live file
+	Size  int64     // the size of the file on disk (in bytes)
+	Codec Codec     // the codec the segment is compressed with, nil if it isn't
+	Live  bool      // indicates whether the segment is the live log file
+
+	fs Fs
+}
+
+// Open opens the segment for reading, transparently decompressing
+// it if it was compressed.
+func (s Segment) Open() (io.ReadCloser, error) {
+	f, err := s.fs.Open(s.Path)
+	if err != nil {
+		return nil, newError(OpOpen, s.Path, err)
+	}
+
+	if s.Codec == nil {
+		return f, nil
+	}
+
+	cr, err := s.Codec.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, newError(OpDecompress, s.Path, err)
+	}
+
+	return &segmentReader{ReadCloser: cr, file: f}, nil
+}
+
+// Stat returns the file info of the file currently at the path of
+// the segment, which for the live segment changes on rotation.
+func (s Segment) Stat() (os.FileInfo, error) {
+	stat, err := s.fs.Stat(s.Path)
+	if err != nil {
+		return nil, newError(OpStat, s.Path, err)
+	}
+
+	return stat, nil
+}
+
+// segmentReader closes both the decompressor and the file under it.
+type segmentReader struct {
+	io.ReadCloser
+	file io.Closer
+}
+
+func (r *segmentReader) Close() error {
+	return errors.Join(r.ReadCloser.Close(), r.file.Close())
+}
+
+// Segments discovers the segments of the log file with the given
+// name, using the same options as New to find the filesystem and
+// the naming scheme of the backups. The segments are ordered from
+// the oldest to the newest one, which is the live log file if it
+// exists. The start of each segment is the end of the previous one,
+// so the start of the oldest segment is unknown.
+func Segments(name string, opts ...Option) ([]Segment, error) {
+	c, err := NewConfig(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	backups, err := listBackups(c.Fs, name, c.RotatePostfix)
+	if err != nil {
+		return nil, err
+	}
+
+	backups = withoutPartial(backups)
+
+	var (
+		segments = make([]Segment, 0, len(backups)+1)
+		start    time.Time
+	)
+
+	for i := len(backups) - 1; i >= 0; i-- {
+		b := backups[i]
+
+		codec, _ := CodecByExt(b.Path)
+
+		segments = append(segments, Segment{
+			Path:  b.Path,
+			Start: start,
+			End:   b.Time,
+			Size:  b.Size,
+			Codec: codec,
+			fs:    c.Fs,
+		})
+
+		start = b.Time
+	}
+
+	live := LiveSegment(name, opts...)
+
+	stat, err := live.Stat()
+	if errors.Is(err, os.ErrNotExist) {
+		return segments, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	live.Start = start
+	live.Size = stat.Size()
+
+	return append(segments, live), nil
+}
+
+// LiveSegment returns the segment of the live log file with the
+// given name, using the same options as New to find the filesystem.
+// The file may not exist yet, and its start and size are unknown.
+func LiveSegment(name string, opts ...Option) Segment {
+	// Only the filesystem is used, so the other settings don't have
+	// to be valid.
+	c, _ := NewConfig(opts...)
+
+	return Segment{
+		Path: name,
+		Live: true,
+		fs:   c.Fs,
+	}
+}
@@ -0,0 +1,45 @@
+package filewriter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSegments(t *testing.T) {
+	afs := &afero.Afero{Fs: afero.NewMemMapFs()}
+
+	name := "logs/test.log"
+	now := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+
+	files := []string{
+		name,
+		name + "." + now.Add(-1*time.Hour).Format(time.RFC3339),
+		name + "." + now.Add(-1*time.Hour).Format(time.RFC3339) + ".gz",
+		name + "." + now.Add(-2*time.Hour).Format(time.RFC3339) + ".gz",
+	}
+
+	for _, file := range files {
+		err := afs.WriteFile(file, []byte("data"), 0o644)
+		require.NoError(t, err, "expected no error when creating '%s', got '%v'", file, err)
+	}
+
+	segments, err := Segments(name, WithFileSystem(afs.Fs))
+	require.NoError(t, err, "expected no error when listing segments, got '%v'", err)
+
+	var paths []string
+	for _, s := range segments {
+		paths = append(paths, s.Path)
+	}
+
+	expected := []string{files[3], files[1], files[0]}
+	require.Equal(t, expected, paths, "unexpected segments")
+
+	require.NotNil(t, segments[0].Codec, "expected oldest backup to be compressed")
+	require.True(t, segments[0].Start.IsZero(), "expected unknown start of oldest backup")
+	require.Nil(t, segments[1].Codec, "expected pending backup to be uncompressed")
+	require.Equal(t, now.Add(-2*time.Hour), segments[1].Start, "unexpected start of pending backup")
+	require.True(t, segments[2].Live, "expected last segment to be the live file")
+}
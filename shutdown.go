@@ -0,0 +1,132 @@
+package filewriter
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// IncompleteError is returned by CloseContext and FlushContext when
+// the context ended before all the work was done. It wraps the error
+// of the context.
+type IncompleteError struct {
+	Err error // the error of the context
+
+	// the records of the asynchronous queue that weren't written;
+	// CloseContext drops them, while FlushContext leaves them queued
+	Records int
+	// indicates whether the lock couldn't be taken in time, e.g.
+	// because of a slow fsync, so nothing was flushed
+	Busy bool
+	// indicates whether the rotation asked for by the RotatePolicy
+	// was skipped
+	Rotation bool
+	// indicates whether the fsync of the log file was skipped
+	Unsynced bool
+	// the backups whose compression was abandoned; they are kept
+	// uncompressed and can be compressed later with CompressBackups
+	Uncompressed []string
+}
+
+func (e *IncompleteError) Error() string {
+	var left []string
+
+	if e.Busy {
+		left = append(left, "log file busy, buffer not flushed")
+	}
+
+	if e.Records > 0 {
+		left = append(left, fmt.Sprintf("%d queued records not written", e.Records))
+	}
+
+	if e.Rotation {
+		left = append(left, "rotation skipped")
+	}
+
+	if e.Unsynced {
+		left = append(left, "log file not fsynced")
+	}
+
+	if len(e.Uncompressed) > 0 {
+		left = append(left, fmt.Sprintf("%d backups left uncompressed", len(e.Uncompressed)))
+	}
+
+	return fmt.Sprintf("work left unfinished (%v): %s", e.Err, strings.Join(left, ", "))
+}
+
+func (e *IncompleteError) Unwrap() error {
+	return e.Err
+}
+
+// result returns the error if ctx ended and something was left
+// unfinished, and nil otherwise.
+func (e *IncompleteError) result(ctx context.Context) error {
+	if ctx.Err() == nil {
+		return nil
+	}
+
+	if !e.Busy && e.Records == 0 && !e.Rotation && !e.Unsynced && len(e.Uncompressed) == 0 {
+		return nil
+	}
+
+	e.Err = ctx.Err()
+
+	return e
+}
+
+// withMargin returns a context ending margin before the deadline of
+// ctx, or along with ctx if it has no deadline.
+func withMargin(ctx context.Context, margin time.Duration) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithDeadline(ctx, deadline.Add(-margin))
+}
+
+// lockContext takes fw.mu, giving up once ctx ends. It reports
+// whether the lock was taken.
+func (fw *FileWriter) lockContext(ctx context.Context) bool {
+	if ctx.Done() == nil {
+		fw.mu.Lock()
+		return true
+	}
+
+	if fw.mu.TryLock() {
+		return true
+	}
+
+	// sync.Mutex can't be waited for along with a channel, so the
+	// lock is polled instead.
+	ticker := time.NewTicker(time.Millisecond)
+	defer ticker.Stop()
+
+	for !fw.mu.TryLock() {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+		}
+	}
+
+	return true
+}
+
+// ctxReader fails reading once the context ends, which makes a copy
+// from it abandonable.
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r ctxReader) Read(p []byte) (int, error) {
+	err := r.ctx.Err()
+	if err != nil {
+		return 0, err
+	}
+
+	return r.r.Read(p)
+}
@@ -0,0 +1,161 @@
+package filewriter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCloseContextCanceled(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	currentTime = func() time.Time { return now }
+	defer func() { currentTime = time.Now }()
+
+	afs := &afero.Afero{Fs: afero.NewMemMapFs()}
+	payload := []byte("Hello, world!\n")
+
+	fw, err := New(
+		"test.log",
+		WithFileSystem(afs),
+		WithLogFlushInterval(0),
+		WithSyncMode(SyncOnRotate),
+		WithRotatePolicy(IntervalPolicy{Interval: time.Hour}),
+	)
+	require.NoError(t, err, "expected no error when creating file writer, got '%v'", err)
+
+	_, err = fw.Write(payload)
+	require.NoError(t, err, "expected no error when writing, got '%v'", err)
+
+	now = now.Add(time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = fw.CloseContext(ctx)
+
+	var incomplete *IncompleteError
+	require.ErrorAs(t, err, &incomplete, "expected an incomplete error, got '%v'", err)
+	require.ErrorIs(t, err, context.Canceled, "expected the error to wrap the context error")
+	require.True(t, incomplete.Rotation, "expected rotation to be skipped")
+	require.True(t, incomplete.Unsynced, "expected fsync to be skipped")
+
+	content, err := afs.ReadFile("test.log")
+	require.NoError(t, err, "expected no error when reading file, got '%v'", err)
+	require.Equal(t, payload, content, "expected buffered data to be flushed")
+}
+
+func TestCloseContextCompressMargin(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	currentTime = func() time.Time { return now }
+	t.Cleanup(func() { currentTime = time.Now })
+
+	afs := &afero.Afero{Fs: afero.NewMemMapFs()}
+
+	fw, err := New(
+		"test.log",
+		WithFileSystem(afs),
+		WithLogFlushInterval(0),
+		WithRotatePolicy(IntervalPolicy{Interval: time.Hour}),
+	)
+	require.NoError(t, err, "expected no error when creating file writer, got '%v'", err)
+
+	_, err = fw.Write([]byte("Hello, world!\n"))
+	require.NoError(t, err, "expected no error when writing, got '%v'", err)
+
+	now = now.Add(time.Hour)
+
+	// The deadline is closer than closeCompressMargin, so the backup
+	// rotated on close is left uncompressed.
+	ctx, cancel := context.WithTimeout(context.Background(), closeCompressMargin/2)
+	defer cancel()
+
+	err = fw.CloseContext(ctx)
+
+	var incomplete *IncompleteError
+	require.ErrorAs(t, err, &incomplete, "expected an incomplete error, got '%v'", err)
+	require.ErrorIs(t, err, context.DeadlineExceeded, "expected the error to wrap the deadline")
+	require.False(t, incomplete.Rotation, "expected the rotation to be done")
+	require.Len(t, incomplete.Uncompressed, 1, "expected the backup to be left uncompressed")
+	require.NoError(t, ctx.Err(), "expected compression to be abandoned before the deadline")
+
+	exists, _ := afs.Exists(incomplete.Uncompressed[0])
+	require.True(t, exists, "expected the uncompressed backup to be kept")
+}
+
+func TestCloseContextCompleted(t *testing.T) {
+	fw, err := New("test.log", WithFileSystem(afero.NewMemMapFs()), WithLogFlushInterval(0))
+	require.NoError(t, err, "expected no error when creating file writer, got '%v'", err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	err = fw.CloseContext(ctx)
+	require.NoError(t, err, "expected no error when closing in time, got '%v'", err)
+}
+
+func TestFlushContextBusy(t *testing.T) {
+	fw, err := New("test.log", WithFileSystem(afero.NewMemMapFs()), WithLogFlushInterval(0))
+	require.NoError(t, err, "expected no error when creating file writer, got '%v'", err)
+	defer fw.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	fw.mu.Lock()
+	err = fw.FlushContext(ctx)
+	fw.mu.Unlock()
+
+	var incomplete *IncompleteError
+	require.ErrorAs(t, err, &incomplete, "expected an incomplete error, got '%v'", err)
+	require.True(t, incomplete.Busy, "expected the log file to be reported busy")
+	require.ErrorIs(t, err, context.DeadlineExceeded, "expected the error to wrap the context error")
+}
+
+func TestFlushContextAsync(t *testing.T) {
+	afs := &afero.Afero{Fs: afero.NewMemMapFs()}
+	payload := []byte("Hello, world!\n")
+
+	fw, err := New(
+		"test.log",
+		WithFileSystem(afs),
+		WithLogFlushInterval(0),
+		WithAsyncWrite(8, OverflowBlock),
+	)
+	require.NoError(t, err, "expected no error when creating file writer, got '%v'", err)
+	defer fw.Close()
+
+	for range 4 {
+		_, err = fw.Write(payload)
+		require.NoError(t, err, "expected no error when writing, got '%v'", err)
+	}
+
+	err = fw.FlushContext(context.Background())
+	require.NoError(t, err, "expected no error when flushing, got '%v'", err)
+
+	content, err := afs.ReadFile("test.log")
+	require.NoError(t, err, "expected no error when reading file, got '%v'", err)
+	require.Len(t, content, 4*len(payload), "expected every queued record to be written")
+}
+
+func TestCompressFileCanceled(t *testing.T) {
+	afs := &afero.Afero{Fs: afero.NewMemMapFs()}
+
+	err := afs.WriteFile("test.log.1", []byte("Hello, world!\n"), 0644)
+	require.NoError(t, err, "expected no error when writing file, got '%v'", err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = compressFile(ctx, afs, "test.log.1", "test.log.1.gz", 0644, Gzip, LevelDefault, false)
+	require.True(t, errors.Is(err, context.Canceled), "expected the compression to be abandoned, got '%v'", err)
+
+	exists, _ := afs.Exists("test.log.1.gz")
+	require.False(t, exists, "expected the partial backup to be removed")
+
+	exists, _ = afs.Exists("test.log.1")
+	require.True(t, exists, "expected the source to be kept")
+}
@@ -0,0 +1,54 @@
+package filewriter
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+)
+
+// HandleSignals calls Reopen whenever one of the reopen signals is
+// received and Rotate whenever one of the rotate signals is. Errors
+// are reported through the ErrorHandler. The returned function stops
+// handling the signals and may be called more than once.
+func (fw *FileWriter) HandleSignals(reopen, rotate []os.Signal) (stop func()) {
+	reopenCh := make(chan os.Signal, 1)
+	rotateCh := make(chan os.Signal, 1)
+	done := make(chan struct{})
+
+	if len(reopen) > 0 {
+		signal.Notify(reopenCh, reopen...)
+	}
+
+	if len(rotate) > 0 {
+		signal.Notify(rotateCh, rotate...)
+	}
+
+	go func() {
+		for {
+			var err error
+
+			select {
+			case <-done:
+				return
+			case <-reopenCh:
+				err = fw.Reopen()
+			case <-rotateCh:
+				err = fw.Rotate()
+			}
+
+			if err != nil {
+				fw.handleError(err)
+			}
+		}
+	}()
+
+	var once sync.Once
+
+	return func() {
+		once.Do(func() {
+			signal.Stop(reopenCh)
+			signal.Stop(rotateCh)
+			close(done)
+		})
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package filewriter
+
+// HandleDefaultSignals does nothing on platforms without SIGHUP and
+// SIGUSR1.
+func (fw *FileWriter) HandleDefaultSignals() (stop func()) {
+	return func() {}
+}
@@ -0,0 +1,17 @@
+//go:build unix
+
+package filewriter
+
+import (
+	"os"
+	"syscall"
+)
+
+// HandleDefaultSignals follows the Unix daemon conventions: the log
+// file is reopened on SIGHUP and rotated on SIGUSR1.
+func (fw *FileWriter) HandleDefaultSignals() (stop func()) {
+	return fw.HandleSignals(
+		[]os.Signal{syscall.SIGHUP},
+		[]os.Signal{syscall.SIGUSR1},
+	)
+}
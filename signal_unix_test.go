@@ -0,0 +1,48 @@
+//go:build unix
+
+package filewriter
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleDefaultSignals(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "test.log")
+
+	fw, err := New(fileName, WithFileCompress(false), WithLogFlushInterval(0))
+	require.NoError(t, err, "expected no error when creating file writer, got '%v'", err)
+	defer fw.Close()
+
+	stop := fw.HandleDefaultSignals()
+	defer stop()
+
+	_, err = fw.Write([]byte("Hello, world!\n"))
+	require.NoError(t, err, "expected no error when writing, got '%v'", err)
+
+	err = syscall.Kill(syscall.Getpid(), syscall.SIGUSR1)
+	require.NoError(t, err, "expected no error when sending signal, got '%v'", err)
+
+	require.Eventually(t, func() bool {
+		backups, err := listBackups(fw.Fs, fileName, fw.RotatePostfix)
+		return err == nil && len(backups) == 1
+	}, time.Second, 10*time.Millisecond, "expected log file to be rotated on SIGUSR1")
+}
+
+func TestHandleSignalsStop(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "test.log")
+
+	fw, err := New(fileName, WithFileCompress(false), WithLogFlushInterval(0))
+	require.NoError(t, err, "expected no error when creating file writer, got '%v'", err)
+	defer fw.Close()
+
+	stop := fw.HandleSignals([]os.Signal{syscall.SIGHUP}, []os.Signal{syscall.SIGUSR1})
+
+	stop()
+	require.NotPanics(t, stop, "expected stopping twice not to panic")
+}
@@ -0,0 +1,225 @@
+package filewriter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+)
+
+// SlogRoute sends the records within a range of levels to a
+// FileWriter. A nil MinLevel or MaxLevel leaves the range unbounded
+// on that side.
+type SlogRoute struct {
+	Writer   *FileWriter
+	MinLevel slog.Leveler
+	MaxLevel slog.Leveler
+}
+
+func (r SlogRoute) matches(level slog.Level) bool {
+	if r.MinLevel != nil && level < r.MinLevel.Level() {
+		return false
+	}
+
+	return r.MaxLevel == nil || level <= r.MaxLevel.Level()
+}
+
+// SlogHandlerOptions configures a SlogHandler. The embedded
+// slog.HandlerOptions are passed to the handlers encoding the
+// records.
+type SlogHandlerOptions struct {
+	slog.HandlerOptions
+
+	// Text makes the records encoded with slog.TextHandler instead
+	// of slog.JSONHandler.
+	Text bool
+
+	// FlushLevel is the minimum level of the records flushed to the
+	// log file as soon as they are written, e.g. slog.LevelError so
+	// that an error logged before a crash isn't lost in the buffer.
+	// A nil FlushLevel leaves flushing to the FileWriter.
+	FlushLevel slog.Leveler
+}
+
+// SlogHandler is a slog.Handler writing every record to the
+// FileWriters of the routes matching its level. Each record is
+// encoded into a pooled buffer and handed over to the FileWriter in
+// a single Write, or WriteUrgent at the FlushLevel, so it takes the
+// lock once and always lands in one log file.
+type SlogHandler struct {
+	level      slog.Leveler
+	flushLevel slog.Leveler
+	routes     []*slogRoute
+}
+
+// slogRoute is a route along with the pool of encoders of its
+// records.
+type slogRoute struct {
+	SlogRoute
+
+	// newHandler returns a handler encoding the records into w,
+	// with the attributes and groups of the SlogHandler applied.
+	newHandler func(w io.Writer) slog.Handler
+	encoders   sync.Pool
+}
+
+// slogEncoder is a handler bound to the buffer it encodes into. An
+// encoder is used by a single record at a time, so the lock of the
+// slog handler is never contended.
+type slogEncoder struct {
+	buf     bytes.Buffer
+	handler slog.Handler
+}
+
+func newSlogRoute(route SlogRoute, newHandler func(w io.Writer) slog.Handler) *slogRoute {
+	r := &slogRoute{SlogRoute: route, newHandler: newHandler}
+	r.encoders.New = func() any {
+		e := &slogEncoder{}
+		e.handler = newHandler(&e.buf)
+		return e
+	}
+
+	return r
+}
+
+// write encodes the record and writes it to the FileWriter of the
+// route.
+func (r *slogRoute) write(ctx context.Context, rec slog.Record, urgent bool) error {
+	e := r.encoders.Get().(*slogEncoder)
+	defer r.encoders.Put(e)
+
+	e.buf.Reset()
+
+	err := e.handler.Handle(ctx, rec)
+	if err != nil {
+		return err
+	}
+
+	if urgent {
+		_, err = r.Writer.WriteUrgent(e.buf.Bytes())
+	} else {
+		_, err = r.Writer.Write(e.buf.Bytes())
+	}
+
+	return err
+}
+
+var _ slog.Handler = (*SlogHandler)(nil)
+
+// NewSlogHandler returns a handler writing the records to the given
+// routes. A record matching several routes is written to each of
+// them, which allows e.g. errors to be written both to the main log
+// and to a log of their own. A *ConfigError is returned if a route
+// has no Writer.
+func NewSlogHandler(opts *SlogHandlerOptions, routes ...SlogRoute) (*SlogHandler, error) {
+	if opts == nil {
+		opts = &SlogHandlerOptions{}
+	}
+
+	var errs []error
+	for i, route := range routes {
+		if route.Writer == nil {
+			errs = append(errs, &SettingError{Setting: fmt.Sprintf("routes[%d].Writer", i), Reason: "must be set"})
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, &ConfigError{Errs: errs}
+	}
+
+	h := &SlogHandler{
+		level:      opts.Level,
+		flushLevel: opts.FlushLevel,
+		routes:     make([]*slogRoute, len(routes)),
+	}
+
+	if h.level == nil {
+		h.level = slog.LevelInfo
+	}
+
+	handlerOpts := opts.HandlerOptions
+	newHandler := func(w io.Writer) slog.Handler {
+		if opts.Text {
+			return slog.NewTextHandler(w, &handlerOpts)
+		}
+
+		return slog.NewJSONHandler(w, &handlerOpts)
+	}
+
+	for i, route := range routes {
+		h.routes[i] = newSlogRoute(route, newHandler)
+	}
+
+	return h, nil
+}
+
+// SlogHandler returns a handler writing every record to the
+// FileWriter.
+func (fw *FileWriter) SlogHandler(opts *SlogHandlerOptions) *SlogHandler {
+	// The only route has a Writer, so it can't be rejected.
+	h, _ := NewSlogHandler(opts, SlogRoute{Writer: fw})
+	return h
+}
+
+func (h *SlogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	if level < h.level.Level() {
+		return false
+	}
+
+	for _, route := range h.routes {
+		if route.matches(level) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (h *SlogHandler) Handle(ctx context.Context, r slog.Record) error {
+	urgent := h.flushLevel != nil && r.Level >= h.flushLevel.Level()
+
+	var errs []error
+	for _, route := range h.routes {
+		if !route.matches(r.Level) {
+			continue
+		}
+
+		err := route.write(ctx, r, urgent)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.with(func(handler slog.Handler) slog.Handler {
+		return handler.WithAttrs(attrs)
+	})
+}
+
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	return h.with(func(handler slog.Handler) slog.Handler {
+		return handler.WithGroup(name)
+	})
+}
+
+// with returns a copy of the handler with fn applied to the handlers
+// encoding the records of every route.
+func (h *SlogHandler) with(fn func(slog.Handler) slog.Handler) *SlogHandler {
+	h2 := *h
+	h2.routes = make([]*slogRoute, len(h.routes))
+
+	for i, route := range h.routes {
+		newHandler := route.newHandler
+		h2.routes[i] = newSlogRoute(route.SlogRoute, func(w io.Writer) slog.Handler {
+			return fn(newHandler(w))
+		})
+	}
+
+	return &h2
+}
@@ -0,0 +1,115 @@
+package filewriter
+
+import (
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlogHandlerRoutes(t *testing.T) {
+	afs := &afero.Afero{Fs: afero.NewMemMapFs()}
+
+	open := func(name string) *FileWriter {
+		fw, err := New(name, WithFileSystem(afs), WithLogFlushInterval(0))
+		require.NoError(t, err, "expected no error when creating file writer, got '%v'", err)
+		return fw
+	}
+
+	app, errs := open("app.log"), open("error.log")
+
+	h, err := NewSlogHandler(
+		&SlogHandlerOptions{FlushLevel: slog.LevelError},
+		SlogRoute{Writer: app, MaxLevel: slog.LevelWarn},
+		SlogRoute{Writer: errs, MinLevel: slog.LevelError},
+	)
+	require.NoError(t, err, "expected no error when creating handler, got '%v'", err)
+
+	logger := slog.New(h).With("service", "test")
+	logger.Debug("skipped")
+	logger.Info("started")
+	logger.Error("failed")
+
+	// The error was flushed on its own, while the info record is
+	// still buffered.
+	data, err := afs.ReadFile("error.log")
+	require.NoError(t, err, "expected no error when reading error log, got '%v'", err)
+	require.Contains(t, string(data), `"msg":"failed"`, "expected error record to be flushed")
+	require.Contains(t, string(data), `"service":"test"`, "expected attributes to be kept")
+
+	data, err = afs.ReadFile("app.log")
+	require.NoError(t, err, "expected no error when reading app log, got '%v'", err)
+	require.Empty(t, data, "expected info record to be buffered")
+
+	require.NoError(t, app.Close(), "expected no error when closing app log")
+	require.NoError(t, errs.Close(), "expected no error when closing error log")
+
+	data, err = afs.ReadFile("app.log")
+	require.NoError(t, err, "expected no error when reading app log, got '%v'", err)
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 1, "unexpected number of records in app log")
+	require.Contains(t, lines[0], `"msg":"started"`, "unexpected record in app log")
+}
+
+func TestSlogHandlerText(t *testing.T) {
+	afs := &afero.Afero{Fs: afero.NewMemMapFs()}
+
+	fw, err := New("test.log", WithFileSystem(afs), WithLogFlushInterval(0))
+	require.NoError(t, err, "expected no error when creating file writer, got '%v'", err)
+
+	logger := slog.New(fw.SlogHandler(&SlogHandlerOptions{Text: true}))
+	logger.WithGroup("req").Info("handled", "status", 200)
+
+	require.NoError(t, fw.Close(), "expected no error when closing file writer")
+
+	data, err := afs.ReadFile("test.log")
+	require.NoError(t, err, "expected no error when reading log file, got '%v'", err)
+	require.Contains(t, string(data), "msg=handled req.status=200", "unexpected text record")
+}
+
+func TestSlogHandlerNilWriter(t *testing.T) {
+	_, err := NewSlogHandler(nil, SlogRoute{MinLevel: slog.LevelError})
+	require.ErrorIs(t, err, ErrInvalidConfig, "expected a config error, got '%v'", err)
+	require.ErrorContains(t, err, "routes[0].Writer", "expected the route to be named")
+}
+
+func TestSlogHandlerConcurrent(t *testing.T) {
+	afs := &afero.Afero{Fs: afero.NewMemMapFs()}
+
+	fw, err := New("test.log", WithFileSystem(afs), WithLogFlushInterval(0))
+	require.NoError(t, err, "expected no error when creating file writer, got '%v'", err)
+
+	logger := slog.New(fw.SlogHandler(nil)).With("service", "test")
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for j := range 100 {
+				logger.Info("handled", "worker", i, "request", j)
+			}
+		}()
+	}
+
+	wg.Wait()
+	require.NoError(t, fw.Close(), "expected no error when closing file writer")
+
+	data, err := afs.ReadFile("test.log")
+	require.NoError(t, err, "expected no error when reading log file, got '%v'", err)
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 800, "unexpected number of records")
+
+	for _, line := range lines {
+		require.True(t, json.Valid([]byte(line)), "expected every record to be intact, got %q", line)
+		require.Contains(t, line, `"service":"test"`, "expected attributes to be kept")
+	}
+}
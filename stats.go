@@ -0,0 +1,167 @@
+package filewriter
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// errorKind classifies the errors counted in Stats by the operation
+// that failed.
+type errorKind int
+
+const (
+	errorKindOpen errorKind = iota
+	errorKindWrite
+	errorKindFlush
+	errorKindSync
+	errorKindRotate
+	errorKindCompress
+	errorKindRemove
+	errorKindPrune
+	errorKinds
+)
+
+var errorKindNames = [errorKinds]string{
+	errorKindOpen:     "open",
+	errorKindWrite:    "write",
+	errorKindFlush:    "flush",
+	errorKindSync:     "sync",
+	errorKindRotate:   "rotate",
+	errorKindCompress: "compress",
+	errorKindRemove:   "remove",
+	errorKindPrune:    "prune",
+}
+
+// flushLatencyBuckets are the upper bounds of the buckets of the
+// flush latency histogram.
+var flushLatencyBuckets = [...]time.Duration{
+	100 * time.Microsecond,
+	500 * time.Microsecond,
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+}
+
+// Histogram is a snapshot of a latency histogram. Counts[i] is the
+// number of observations not greater than Buckets[i] and greater
+// than the previous bound; the last element of Counts holds the
+// observations greater than every bound.
+type Histogram struct {
+	Buckets []time.Duration
+	Counts  []uint64
+	Count   uint64
+	Sum     time.Duration
+}
+
+// Stats is a snapshot of the counters of a FileWriter.
+type Stats struct {
+	BytesWritten   uint64 // the bytes flushed to log files
+	RecordsWritten uint64 // the records accepted by Write
+	Flushes        uint64 // the flushes of a non-empty buffer
+	FlushLatency   Histogram
+	Rotations      uint64
+
+	Compressions     uint64
+	CompressTime     time.Duration // the total time spent compressing
+	CompressedBytes  uint64        // the size of the files before compression
+	CompressedOutput uint64        // the size of the files after compression
+	// CompressedBytes / CompressedOutput, or 0 if nothing was
+	// compressed yet
+	CompressRatio float64
+
+	Errors  map[string]uint64 // the errors by the kind of failed operation
+	Dropped uint64            // the records dropped by the asynchronous writer
+}
+
+// metrics holds the counters of a FileWriter. They are updated with
+// atomic operations, since compression workers update them without
+// holding fw.mu, and Stats reads them without taking it.
+type metrics struct {
+	bytesWritten   atomic.Uint64
+	recordsWritten atomic.Uint64
+	flushes        atomic.Uint64
+	flushCounts    [len(flushLatencyBuckets) + 1]atomic.Uint64
+	flushNanos     atomic.Uint64
+	rotations      atomic.Uint64
+
+	compressions     atomic.Uint64
+	compressNanos    atomic.Uint64
+	compressedBytes  atomic.Uint64
+	compressedOutput atomic.Uint64
+
+	errors [errorKinds]atomic.Uint64
+}
+
+func (m *metrics) observeFlush(d time.Duration) {
+	i := 0
+	for i < len(flushLatencyBuckets) && d > flushLatencyBuckets[i] {
+		i++
+	}
+
+	m.flushes.Add(1)
+	m.flushCounts[i].Add(1)
+	m.flushNanos.Add(uint64(d))
+}
+
+func (m *metrics) observeCompress(d time.Duration, in, out int64) {
+	m.compressions.Add(1)
+	m.compressNanos.Add(uint64(d))
+	m.compressedBytes.Add(uint64(in))
+	m.compressedOutput.Add(uint64(out))
+}
+
+// countError counts err, if it isn't nil, under the given kind and
+// returns it unchanged.
+func (m *metrics) countError(kind errorKind, err error) error {
+	if err != nil {
+		m.errors[kind].Add(1)
+	}
+
+	return err
+}
+
+// Stats returns a snapshot of the counters of the FileWriter. It
+// doesn't take the lock, so it can be called at any time, although
+// the counters may change while the snapshot is taken.
+func (fw *FileWriter) Stats() Stats {
+	m := &fw.metrics
+
+	s := Stats{
+		BytesWritten:   m.bytesWritten.Load(),
+		RecordsWritten: m.recordsWritten.Load(),
+		Flushes:        m.flushes.Load(),
+		FlushLatency: Histogram{
+			Buckets: append([]time.Duration(nil), flushLatencyBuckets[:]...),
+			Counts:  make([]uint64, len(m.flushCounts)),
+			Sum:     time.Duration(m.flushNanos.Load()),
+		},
+		Rotations: m.rotations.Load(),
+
+		Compressions:     m.compressions.Load(),
+		CompressTime:     time.Duration(m.compressNanos.Load()),
+		CompressedBytes:  m.compressedBytes.Load(),
+		CompressedOutput: m.compressedOutput.Load(),
+
+		Errors:  make(map[string]uint64, errorKinds),
+		Dropped: fw.Dropped(),
+	}
+
+	for i := range m.flushCounts {
+		s.FlushLatency.Counts[i] = m.flushCounts[i].Load()
+		s.FlushLatency.Count += s.FlushLatency.Counts[i]
+	}
+
+	if s.CompressedOutput > 0 {
+		s.CompressRatio = float64(s.CompressedBytes) / float64(s.CompressedOutput)
+	}
+
+	for kind, name := range errorKindNames {
+		s.Errors[name] = m.errors[kind].Load()
+	}
+
+	return s
+}
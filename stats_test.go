@@ -0,0 +1,86 @@
+package filewriter
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/suite"
+)
+
+type testStatsSuite struct {
+	suite.Suite
+
+	filePayload []byte
+
+	fw *FileWriter
+}
+
+func TestStatsSuite(t *testing.T) {
+	ts := &testStatsSuite{filePayload: []byte("Hello, world!\n")}
+	suite.Run(t, ts)
+}
+
+func (ts *testStatsSuite) SetupTest() {
+	fw, err := New(
+		"test.log",
+		WithFileSystem(afero.NewMemMapFs()),
+		WithLogFlushInterval(0),
+	)
+	ts.Require().NoError(err, "expected no error when creating file writer, got '%v'", err)
+
+	for range 3 {
+		_, err = fw.Write(ts.filePayload)
+		ts.Require().NoError(err, "expected no error when writing, got '%v'", err)
+	}
+
+	err = fw.Rotate()
+	ts.Require().NoError(err, "expected no error when rotating, got '%v'", err)
+
+	ts.fw = fw
+}
+
+func (ts *testStatsSuite) TearDownTest() {
+	ts.fw.Close()
+}
+
+func (ts *testStatsSuite) TestStats() {
+	ts.Require().Eventually(func() bool {
+		return ts.fw.Stats().Compressions == 1
+	}, time.Second, 10*time.Millisecond, "expected the backup to be compressed in the background")
+
+	s := ts.fw.Stats()
+
+	ts.Require().Equal(uint64(3), s.RecordsWritten, "unexpected records written")
+	ts.Require().Equal(uint64(3*len(ts.filePayload)), s.BytesWritten, "unexpected bytes written")
+	ts.Require().Equal(uint64(1), s.Flushes, "unexpected flushes")
+	ts.Require().Equal(uint64(1), s.FlushLatency.Count, "unexpected flush latency observations")
+	ts.Require().Equal(uint64(1), s.Rotations, "unexpected rotations")
+	ts.Require().Equal(uint64(1), s.Compressions, "unexpected compressions")
+	ts.Require().Greater(s.CompressRatio, 0.0, "expected compression ratio to be set")
+	ts.Require().Zero(s.Errors["flush"], "expected no flush errors")
+}
+
+func (ts *testStatsSuite) TestMetricsHandler() {
+	rec := httptest.NewRecorder()
+	ts.fw.MetricsHandler("").ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	body, err := io.ReadAll(rec.Body)
+	ts.Require().NoError(err, "expected no error when reading response, got '%v'", err)
+
+	for _, line := range []string{
+		"# TYPE filewriter_rotations_total counter",
+		"filewriter_rotations_total 1",
+		"filewriter_records_written_total 3",
+		`filewriter_flush_duration_seconds_bucket{le="+Inf"} 1`,
+		`filewriter_errors_total{kind="flush"} 0`,
+	} {
+		ts.Require().True(
+			strings.Contains(string(body), line+"\n"),
+			"expected exposition to contain '%v', got:\n%v", line, string(body),
+		)
+	}
+}
@@ -0,0 +1,92 @@
+package filewriter
+
+import "runtime"
+
+// SyncMode defines when the log file is fsynced, i.e. when the data
+// already passed to the kernel is forced onto the disk.
+type SyncMode int
+
+const (
+	SyncNever         SyncMode = iota // never fsync, leave it to the kernel
+	SyncOnRotate                      // fsync on rotation and close only
+	SyncOnFlush                       // fsync after every flush of the buffer
+	SyncEveryBytes                    // fsync once SyncBytes were flushed since the last fsync
+	SyncEveryInterval                 // fsync on flush if SyncInterval passed since the last fsync
+)
+
+// Sync flushes the buffer and commits the log file to the disk,
+// regardless of the SyncMode.
+func (fw *FileWriter) Sync() error {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	if fw.File == nil {
+		return newError(OpSync, "", ErrClosed)
+	}
+
+	err := fw.flushBuf()
+	if err != nil {
+		return err
+	}
+
+	return fw.syncFile()
+}
+
+func (fw *FileWriter) syncFile() error {
+	err := fw.File.Sync()
+	if err != nil {
+		return fw.metrics.countError(errorKindSync, newError(OpSync, fw.File.Name(), err))
+	}
+
+	fw.unsynced = 0
+	fw.lastSync = currentTime()
+
+	return nil
+}
+
+// syncAfterFlush fsyncs the log file after flushed bytes were
+// written to it, if the SyncMode asks for it.
+func (fw *FileWriter) syncAfterFlush(flushed uint) error {
+	fw.unsynced += flushed
+	if fw.unsynced == 0 {
+		return nil
+	}
+
+	switch fw.SyncMode {
+	case SyncOnFlush:
+	case SyncEveryBytes:
+		if fw.unsynced < fw.SyncBytes {
+			return nil
+		}
+	case SyncEveryInterval:
+		if currentTime().Sub(fw.lastSync) < fw.SyncInterval {
+			return nil
+		}
+	default:
+		return nil
+	}
+
+	return fw.syncFile()
+}
+
+// syncDir commits the directory entries of dir to the disk, which
+// makes a rename or creation of a file in it durable. Directories
+// can't be fsynced on Windows, so it does nothing there.
+func syncDir(fs Fs, dir string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+
+	d, err := fs.Open(dir)
+	if err != nil {
+		return newError(OpSync, dir, err)
+	}
+	defer d.Close()
+
+	err = d.Sync()
+	if err != nil {
+		return newError(OpSync, dir, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package filewriter
+
+import (
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+// syncCountingFile counts the calls to Sync of the wrapped file.
+type syncCountingFile struct {
+	afero.File
+	syncs int
+}
+
+func (f *syncCountingFile) Sync() error {
+	f.syncs++
+	return f.File.Sync()
+}
+
+func TestSyncAfterFlush(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	currentTime = func() time.Time { return now }
+	t.Cleanup(func() { currentTime = time.Now })
+
+	tests := []struct {
+		name     string
+		fw       *FileWriter
+		flushes  []uint
+		step     time.Duration // the time passing before every flush
+		expected int
+	}{
+		{name: "never", fw: &FileWriter{Config: Config{SyncMode: SyncNever}}, flushes: []uint{1, 1}, expected: 0},
+		{name: "on rotate", fw: &FileWriter{Config: Config{SyncMode: SyncOnRotate}}, flushes: []uint{1, 1}, expected: 0},
+		{name: "on flush", fw: &FileWriter{Config: Config{SyncMode: SyncOnFlush}}, flushes: []uint{1, 0, 1}, expected: 2},
+		{
+			name:     "every bytes",
+			fw:       &FileWriter{Config: Config{SyncMode: SyncEveryBytes, SyncBytes: 10}},
+			flushes:  []uint{4, 4, 4, 4},
+			expected: 1,
+		},
+		{
+			name:     "every interval",
+			fw:       &FileWriter{Config: Config{SyncMode: SyncEveryInterval, SyncInterval: time.Second}, lastSync: now},
+			flushes:  []uint{1, 1, 1},
+			step:     600 * time.Millisecond,
+			expected: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := afero.NewMemMapFs().Create("test.log")
+			require.NoError(t, err, "expected no error when creating file, got '%v'", err)
+
+			file := &syncCountingFile{File: f}
+			tt.fw.File = file
+
+			for _, flushed := range tt.flushes {
+				now = now.Add(tt.step)
+
+				err = tt.fw.syncAfterFlush(flushed)
+				require.NoError(t, err, "expected no error when syncing, got '%v'", err)
+			}
+
+			require.Equal(
+				t, tt.expected, file.syncs,
+				"expected %v syncs, got %v",
+				tt.expected, file.syncs,
+			)
+		})
+	}
+}
+
+// dirSyncFailFs fails the fsync of every directory it opens.
+type dirSyncFailFs struct {
+	afero.Fs
+}
+
+func (fs dirSyncFailFs) Open(name string) (afero.File, error) {
+	f, err := fs.Fs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := f.Stat()
+	if err == nil && info.IsDir() {
+		return dirSyncFailFile{File: f}, nil
+	}
+
+	return f, nil
+}
+
+type dirSyncFailFile struct {
+	afero.File
+}
+
+func (f dirSyncFailFile) Sync() error {
+	return syscall.EIO
+}
+
+func TestRotateDirSyncError(t *testing.T) {
+	var (
+		rotated []RotateEvent
+		handled []error
+	)
+
+	fw, err := New(
+		"test.log",
+		WithFileSystem(dirSyncFailFs{Fs: afero.NewMemMapFs()}),
+		WithFileCompress(false),
+		WithLogFlushInterval(0),
+		WithOnRotate(func(e RotateEvent) error {
+			rotated = append(rotated, e)
+			return nil
+		}),
+		WithErrorHandler(func(fw *FileWriter, err error) {
+			handled = append(handled, err)
+		}),
+	)
+	require.NoError(t, err, "expected no error when creating file writer, got '%v'", err)
+	defer fw.Close()
+
+	err = fw.Rotate()
+	require.NoError(t, err, "expected no error when rotating, got '%v'", err)
+
+	require.Len(t, rotated, 1, "expected the rotation to be reported")
+	require.Len(t, handled, 1, "expected the failed fsync to be reported")
+	require.ErrorIs(t, handled[0], syscall.EIO, "expected the cause to be kept, got '%v'", handled[0])
+}
@@ -0,0 +1,25 @@
+package filewriter
+
+import "bytes"
+
+// JSONFieldIn returns a predicate for WithUrgent reporting whether
+// the string value of the given field of a JSON record is one of the
+// values, e.g. JSONFieldIn("level", "error", "fatal", "panic") for
+// zerolog. The record is searched for the field as written by
+// encoding/json, without whitespace around the colon.
+func JSONFieldIn(field string, values ...string) func(p []byte) bool {
+	patterns := make([][]byte, len(values))
+	for i, v := range values {
+		patterns[i] = []byte(`"` + field + `":"` + v + `"`)
+	}
+
+	return func(p []byte) bool {
+		for _, pattern := range patterns {
+			if bytes.Contains(p, pattern) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
@@ -0,0 +1,81 @@
+package filewriter
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONFieldIn(t *testing.T) {
+	urgent := JSONFieldIn("level", "error", "fatal")
+
+	require.True(t, urgent([]byte(`{"level":"error","message":"failed"}`)), "expected error record to be urgent")
+	require.False(t, urgent([]byte(`{"level":"info","message":"error"}`)), "expected info record not to be urgent")
+}
+
+func TestFlushUrgent(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  []Option
+		write func(fw *FileWriter, p []byte) (int, error)
+	}{
+		{
+			name:  "write urgent",
+			write: (*FileWriter).WriteUrgent,
+		},
+		{
+			name:  "urgent predicate",
+			opts:  []Option{WithUrgent(JSONFieldIn("level", "error"))},
+			write: (*FileWriter).Write,
+		},
+		{
+			name:  "async write urgent",
+			opts:  []Option{WithAsyncWrite(16, OverflowBlock)},
+			write: (*FileWriter).WriteUrgent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			afs := &afero.Afero{Fs: afero.NewMemMapFs()}
+
+			opts := append([]Option{WithFileSystem(afs), WithLogFlushInterval(0)}, tt.opts...)
+			fw, err := New("test.log", opts...)
+			require.NoError(t, err, "expected no error when creating file writer, got '%v'", err)
+			defer fw.Close()
+
+			_, err = fw.Write([]byte(`{"level":"info"}` + "\n"))
+			require.NoError(t, err, "expected no error when writing, got '%v'", err)
+
+			_, err = tt.write(fw, []byte(`{"level":"error"}`+"\n"))
+			require.NoError(t, err, "expected no error when writing, got '%v'", err)
+
+			data, err := afs.ReadFile("test.log")
+			require.NoError(t, err, "expected no error when reading log file, got '%v'", err)
+			require.Equal(t, `{"level":"info"}`+"\n"+`{"level":"error"}`+"\n", string(data), "expected both records to be flushed")
+		})
+	}
+}
+
+func TestFlush(t *testing.T) {
+	afs := &afero.Afero{Fs: afero.NewMemMapFs()}
+
+	fw, err := New("test.log", WithFileSystem(afs), WithLogFlushInterval(0))
+	require.NoError(t, err, "expected no error when creating file writer, got '%v'", err)
+
+	_, err = fw.Write([]byte("Hello, world!\n"))
+	require.NoError(t, err, "expected no error when writing, got '%v'", err)
+
+	err = fw.Flush()
+	require.NoError(t, err, "expected no error when flushing, got '%v'", err)
+
+	data, err := afs.ReadFile("test.log")
+	require.NoError(t, err, "expected no error when reading log file, got '%v'", err)
+	require.Equal(t, "Hello, world!\n", string(data), "expected buffer to be flushed")
+
+	require.NoError(t, fw.Close(), "expected no error when closing")
+
+	err = fw.Flush()
+	require.Error(t, err, "expected error when flushing a closed file writer")
+}
@@ -1,180 +1,237 @@
 package filewriter
 
 import (
-	"errors"
-	"fmt"
-	"io"
 	"os"
+	"strconv"
 	"time"
-	"unsafe"
-
-	"github.com/klauspost/compress/gzip"
 )
 
-func (fw *FileWriter) getFileSize(file file) (int64, error) {
+func (fw *FileWriter) getFileStat(file file) (os.FileInfo, error) {
 	stat, err := file.Stat()
 	if err != nil {
-		err = errors.Unwrap(err)
-		return 0, fmt.Errorf(wFailedToGetFileStats, err)
+		return nil, newError(OpStat, file.Name(), err)
 	}
 
-	return stat.Size(), nil
-}
-
-// openFileFn is a wrapper around os.OpenFile that returns a value
-// of type file. This wrapper makes it easier to integrate a
-// function for creating mock files during testing.
-var openFileFn = func(name string, flag int, mode os.FileMode) (file, error) {
-	return os.OpenFile(name, flag, mode)
+	return stat, nil
 }
 
 func (fw *FileWriter) openFile(name string, mode os.FileMode) error {
-	f, err := openFileFn(name, fw.Flags, mode)
+	dir, _ := splitDir(name)
+
+	err := fw.Fs.MkdirAll(dir, defaultDirMode)
 	if err != nil {
-		err = errors.Unwrap(err)
-		return fmt.Errorf(wFailedToOpenLogFile, err)
+		return fw.metrics.countError(errorKindOpen, newError(OpOpen, dir, err))
 	}
 
-	size, err := fw.getFileSize(f)
+	f, err := fw.Fs.OpenFile(name, fw.Flags, mode)
 	if err != nil {
-		return err
+		return fw.metrics.countError(errorKindOpen, newError(OpOpen, name, err))
 	}
 
-	fw.File = f
-	fw.Size = uint(size)
-
-	return nil
-}
-
-// setBufWriter sets the underlying io.Writer for the bufio.Writer
-// stored in fw.buf by using unsafe pointer arithmetic to access
-// its unexported "wr" field. The field offset is defined by
-// bufWriterFieldOffset, which is architecture-dependent. It helps
-// avoid having to call Reset method of the bufio.Writer when
-// rotating the file.
-func (fw *FileWriter) setBufWriter(wr io.Writer) {
-	bufPtr := unsafe.Pointer(fw.Buf)
-	wrPtr := (*io.Writer)(unsafe.Pointer(uintptr(bufPtr) + bufWriterFieldOffset))
-	*wrPtr = wr
-}
-
-func (fw *FileWriter) compress(backupName string) error {
-	dest, err := openFileFn(backupName, defaulFileFlags, fw.Mode)
+	stat, err := fw.getFileStat(f)
 	if err != nil {
 		return err
 	}
-	defer dest.Close()
 
-	gr := gzip.NewWriter(dest)
-	defer gr.Close()
+	size := stat.Size()
+	if fw.Framed {
+		size, err = recoverFrames(f, size)
+		if err != nil {
+			f.Close()
+			return fw.metrics.countError(errorKindOpen, newError(OpRecover, name, err))
+		}
+	}
 
-	// Reset the file pointer to the beginning. Without this,
-	// using the Write method would advance the pointer, causing
-	// subsequent reads to start from the current position rather
-	// than from the beginning.
-	fw.File.Seek(0, 0)
+	fw.File = f
+	fw.Size = uint(size)
 
-	_, err = io.Copy(gr, fw.File)
-	if err != nil {
-		err = errors.Unwrap(err)
-		return fmt.Errorf(wFailedToCompressLogFile, err)
+	// A non-empty file was started by a previous process, so that
+	// time-based rotation policies pick up where it left off. Where
+	// the creation time of the file isn't known, the time it was
+	// last written is used, which is later than when it was started.
+	fw.OpenedAt = currentTime()
+	if stat.Size() > 0 {
+		fw.OpenedAt = stat.ModTime()
+		if born, ok := birthTime(stat); ok && born.Before(fw.OpenedAt) {
+			fw.OpenedAt = born
+		}
 	}
 
 	return nil
 }
 
-var (
-	removeFileFn = func(name string) error {
-		return os.Remove(name)
+// currentTime is a variable that holds the function for obtaining
+// the current time. It is extracted into a variable to facilitate
+// testing, allowing it to be replaced with a mock function.
+var currentTime = time.Now
+
+// backupName returns the name the log file is renamed to on
+// rotation. If a backup with the same timestamp already exists,
+// which happens when the file is rotated more than once within the
+// resolution of RotatePostfix, a sequence number is appended to keep
+// the backup from being overwritten.
+func (fw *FileWriter) backupName(name string) string {
+	base := name + "." + currentTime().Format(fw.RotatePostfix)
+
+	backupName := base
+	for seq := 1; fw.backupExists(backupName); seq++ {
+		backupName = base + "." + strconv.Itoa(seq)
 	}
 
-	// renameFileFn is a wrapper around os.Rename that returns a value
-	// renames the file. This wrapper makes it easier to integrate a
-	// function for renaming mock files during testing.
-	renameFileFn = func(oldpath, newpath string) error {
-		return os.Rename(oldpath, newpath)
+	return backupName
+}
+
+func (fw *FileWriter) backupExists(name string) bool {
+	_, err := fw.Fs.Stat(name)
+	if err == nil {
+		return true
 	}
 
-	// currentTime is a variable that holds the function for obtaining
-	// the current time. It is extracted into a variable to facilitate
-	// testing, allowing it to be replaced with a mock function.
-	currentTime = time.Now
-)
+	if fw.Compress {
+		_, err = fw.Fs.Stat(name + fw.Codec.Ext())
+		return err == nil
+	}
+
+	return false
+}
 
 // rotate performs log file rotation. It closes the current log
 // file, renames it with a timestamp postfix, and opens a new
 // one with the original name. It also updates the fw.size field to
 // the size of the data currently buffered, without taking into
 // account the size of the newly created file, cause it assumed to
-// be empty.
+// be empty. Unless SyncMode is SyncNever, the finished file is
+// fsynced before the rename and the directory after it, so that the
+// rotation survives a power loss. Once the new file is open, the
+// OnRotate hooks are queued, the backup is handed over to the
+// background compressor, and pruning of the backups that exceed the
+// retention limits is scheduled.
 func (fw *FileWriter) rotateFile() error {
 	name := fw.File.Name()
-
-	err := func() error {
-		defer fw.File.Close()
-
-		var err error
-		if fw.DeleteOld {
-			err = removeFileFn(name)
+	size, start := fw.Size, fw.OpenedAt
+
+	// The current file is only closed once the new one is open, so
+	// that a failed rotation leaves the FileWriter writing to it.
+	var backupName string
+	if fw.DeleteOld {
+		err := fw.Fs.Remove(name)
+		if err != nil {
+			err = newError(OpRemove, name, err)
+			return fw.metrics.countError(errorKindRemove, err)
+		}
+	} else {
+		if fw.SyncMode != SyncNever {
+			err := fw.syncFile()
 			if err != nil {
-				err = errors.Unwrap(err)
-				return fmt.Errorf(wFailedToRemoveLogFile, err)
-			}
-
-		} else {
-			postfix := currentTime().Format(fw.RotatePostfix)
-			backupName := name + "." + postfix
-
-			if fw.Compress {
-				backupName = backupName + ".gz"
-				err = fw.compress(backupName)
-				if err != nil {
-					removeFileFn(backupName)
-					return err
-				}
-
-				removeFileFn(name)
-
-			} else {
-				err := renameFileFn(name, backupName)
-				if err != nil {
-					err = errors.Unwrap(err)
-					return fmt.Errorf(wFailedToRenameLogFile, err)
-				}
+				return err
 			}
 		}
 
-		return nil
-	}()
+		backupName = fw.backupName(name)
 
-	if err != nil {
-		return err
+		err := fw.Fs.Rename(name, backupName)
+		if err != nil {
+			err = newError(OpRotate, name, err)
+			return fw.metrics.countError(errorKindRotate, err)
+		}
 	}
 
-	f, err := openFileFn(name, fw.Flags, fw.Mode)
+	f, err := fw.Fs.OpenFile(name, fw.Flags, fw.Mode)
 	if err != nil {
-		err = errors.Unwrap(err)
-		return fmt.Errorf(wFailedToOpenLogFile, err)
+		// The backup is moved back, so that the next rotation finds
+		// the file the FileWriter keeps writing to under its name.
+		if backupName != "" {
+			fw.Fs.Rename(backupName, name)
+		}
+
+		return fw.metrics.countError(errorKindOpen, newError(OpOpen, name, err))
 	}
 
+	fw.File.Close()
+
 	fw.File = f
 	fw.Size = 0
+	fw.OpenedAt = currentTime()
 	fw.Wc.wr = f
-	fw.setBufWriter(fw.Wc)
+	fw.metrics.rotations.Add(1)
+	fw.subs.notify()
+
+	// The rotation is done once the new file is open, so a failed
+	// fsync of the directory is reported through the ErrorHandler
+	// instead of failing the write that triggered the rotation.
+	if fw.SyncMode != SyncNever {
+		dir, _ := splitDir(name)
+
+		err = syncDir(fw.Fs, dir)
+		if err != nil {
+			fw.ErrorHandler(fw, fw.metrics.countError(errorKindSync, err))
+		}
+	}
+
+	event := RotateEvent{
+		Path:   name,
+		Backup: backupName,
+		Size:   size,
+		Start:  start,
+		End:    fw.OpenedAt,
+	}
+
+	compress := backupName != "" && fw.Compress
+	if compress {
+		event.Codec = fw.Codec.Name()
+	}
+
+	queueHooks(fw, fw.OnRotate, event, fw.ErrorHandler)
+
+	if compress {
+		fw.compressBackup(compressJob{
+			fs:           fw.Fs,
+			src:          backupName,
+			mode:         fw.Mode,
+			codec:        fw.Codec,
+			level:        fw.CompressLevel,
+			sync:         fw.SyncMode != SyncNever,
+			retention:    fw.retention(),
+			errorHandler: fw.ErrorHandler,
+			onCompressed: fw.OnCompressed,
+		})
+
+		return nil
+	}
+
+	fw.schedulePrune(fw.retention())
 
 	return nil
 }
 
 func (fw *FileWriter) flushBuf() error {
-	err := fw.Buf.Flush()
+	var err error
+	if fw.Buf.Buffered() > 0 {
+		start := time.Now()
+		err = fw.Buf.Flush()
+		fw.metrics.observeFlush(time.Since(start))
+	}
 
-	fw.Size += fw.Wc.flushedBytes
-	fw.Wc.flushedBytes = 0
+	flushed := fw.accountFlushed()
 
 	if err != nil {
-		err = errors.Unwrap(err)
-		return fmt.Errorf(wFailedToFlushLogBuffer, err)
+		return fw.metrics.countError(errorKindFlush, newError(OpFlush, fw.File.Name(), err))
 	}
 
-	return nil
+	return fw.syncAfterFlush(flushed)
+}
+
+// accountFlushed moves the bytes written to the log file since the
+// last call from the write counter to fw.Size and returns them.
+func (fw *FileWriter) accountFlushed() uint {
+	flushed := fw.Wc.flushedBytes
+	fw.Size += flushed
+	fw.Wc.flushedBytes = 0
+	fw.metrics.bytesWritten.Add(uint64(flushed))
+
+	if flushed > 0 {
+		fw.subs.notify()
+	}
+
+	return flushed
 }
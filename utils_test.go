@@ -1,9 +1,7 @@
 package filewriter
 
 import (
-	"bufio"
 	"bytes"
-	"os"
 	"testing"
 	"time"
 
@@ -28,11 +26,13 @@ func TestUtilsSuite(t *testing.T) {
 	wc := &writeCounter{wr: &writer}
 
 	fw := &FileWriter{
-		Mode:          defaulFileMode,
-		Flags:         defaulFileFlags,
-		RotatePostfix: defaultFileRotatePostfix,
-		Wc:            wc,
-		Buf:           bufio.NewWriter(wc),
+		Config: Config{
+			Mode:          defaulFileMode,
+			Flags:         defaulFileFlags,
+			RotatePostfix: defaultFileRotatePostfix,
+		},
+		Wc:  wc,
+		Buf: NewBuffer(wc, 0),
 	}
 
 	filePayload := []byte("Hello, world!\n")
@@ -44,13 +44,7 @@ func TestUtilsSuite(t *testing.T) {
 		fw:          fw,
 	}
 
-	openFileFn = func(name string, flag int, mode os.FileMode) (file, error) {
-		return tu.afs.OpenFile(name, flag, mode)
-	}
-
-	renameFileFn = func(oldpath, newpath string) error {
-		return tu.afs.Rename(oldpath, newpath)
-	}
+	fw.Fs = tu.afs
 
 	suite.Run(t, tu)
 }
@@ -58,7 +52,7 @@ func TestUtilsSuite(t *testing.T) {
 func (tu *testUtilsSuite) SetupTest() {
 	var writer bytes.Buffer
 	tu.fw.Wc = &writeCounter{wr: &writer}
-	tu.fw.Buf = bufio.NewWriter(tu.fw.Wc)
+	tu.fw.Buf = NewBuffer(tu.fw.Wc, 0)
 }
 
 func (tu *testUtilsSuite) TearDownSuite() {
@@ -82,31 +76,8 @@ func (tu *testUtilsSuite) TestGetOpenFile() {
 	)
 }
 
-func (tu *testUtilsSuite) TestSetBufWriter() {
-	var oldWriter bytes.Buffer
-	tu.fw.Buf = bufio.NewWriter(&oldWriter)
-
-	var newWriter bytes.Buffer
-	tu.fw.setBufWriter(&newWriter)
-
-	tu.fw.Buf.Write(tu.filePayload)
-	tu.fw.Buf.Flush()
-
-	tu.Require().Emptyf(
-		oldWriter.Bytes(),
-		"expected old writer to be empty, got '%v'",
-		oldWriter.String(),
-	)
-
-	tu.Require().Equal(
-		tu.filePayload, newWriter.Bytes(),
-		"expected new writer to hold '%v', got '%v'",
-		string(tu.filePayload), newWriter.String(),
-	)
-}
-
 func (tu *testUtilsSuite) TestRotateFile() {
-	file, err := openFileFn(tu.fileName, tu.fw.Flags, tu.fw.Mode)
+	file, err := tu.fw.Fs.OpenFile(tu.fileName, tu.fw.Flags, tu.fw.Mode)
 	msg := "expected no error when oppening file, got '%v'"
 	tu.Require().NoError(err, msg, err)
 
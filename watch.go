@@ -0,0 +1,57 @@
+package filewriter
+
+import (
+	"errors"
+	"os"
+)
+
+// checkFile detects changes made to the log file behind the back
+// of the FileWriter, e.g. by logrotate. If the file was moved or
+// deleted, a new file is opened at the original path. If it was
+// truncated, as logrotate does with copytruncate, or appended to by
+// another process, fw.Size is synchronized with the actual size.
+func (fw *FileWriter) checkFile() error {
+	name := fw.File.Name()
+
+	current, err := fw.getFileStat(fw.File)
+	if err != nil {
+		return err
+	}
+
+	stat, err := fw.Fs.Stat(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return fw.reopenFile()
+	}
+
+	if err != nil {
+		return newError(OpStat, name, err)
+	}
+
+	if !SameFile(current, stat) {
+		return fw.reopenFile()
+	}
+
+	fw.Size = uint(current.Size())
+
+	return nil
+}
+
+// reopenFile opens the log file at the same path and closes the
+// old one. The buffered data is not flushed to the old file, since
+// it may already be gone; it is written to the new file instead.
+// If the new file can't be opened, the old one is kept.
+func (fw *FileWriter) reopenFile() error {
+	old := fw.File
+
+	err := fw.openFile(old.Name(), fw.Mode)
+	if err != nil {
+		return err
+	}
+
+	old.Close()
+
+	fw.Wc.wr = fw.File
+	fw.subs.notify()
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package filewriter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type testWatchSuite struct {
+	suite.Suite
+
+	fileName    string
+	filePayload []byte
+
+	fw *FileWriter
+}
+
+func TestWatchSuite(t *testing.T) {
+	tw := &testWatchSuite{filePayload: []byte("Hello, world!\n")}
+	suite.Run(t, tw)
+}
+
+func (tw *testWatchSuite) SetupTest() {
+	tw.fileName = filepath.Join(tw.T().TempDir(), "test.log")
+
+	fw, err := New(tw.fileName, WithFileWatch(true), WithLogFlushInterval(time.Hour))
+	tw.Require().NoError(err, "expected no error when creating file writer, got '%v'", err)
+
+	_, err = fw.Write(tw.filePayload)
+	tw.Require().NoError(err, "expected no error when writing, got '%v'", err)
+
+	err = fw.Sync()
+	tw.Require().NoError(err, "expected no error when syncing, got '%v'", err)
+
+	tw.fw = fw
+}
+
+func (tw *testWatchSuite) TearDownTest() {
+	tw.fw.Close()
+}
+
+func (tw *testWatchSuite) requireReopened() {
+	_, err := tw.fw.Write(tw.filePayload)
+	tw.Require().NoError(err, "expected no error when writing, got '%v'", err)
+
+	err = tw.fw.Sync()
+	tw.Require().NoError(err, "expected no error when syncing, got '%v'", err)
+
+	content, err := os.ReadFile(tw.fileName)
+	tw.Require().NoError(err, "expected no error when reading file, got '%v'", err)
+
+	tw.Require().Equal(
+		tw.filePayload, content,
+		"expected reopened file to hold '%v', got '%v'",
+		string(tw.filePayload), string(content),
+	)
+}
+
+func (tw *testWatchSuite) TestMoved() {
+	err := os.Rename(tw.fileName, tw.fileName+".1")
+	tw.Require().NoError(err, "expected no error when renaming file, got '%v'", err)
+
+	err = tw.fw.checkFile()
+	tw.Require().NoError(err, "expected no error when checking file, got '%v'", err)
+
+	tw.requireReopened()
+}
+
+func (tw *testWatchSuite) TestDeleted() {
+	err := os.Remove(tw.fileName)
+	tw.Require().NoError(err, "expected no error when removing file, got '%v'", err)
+
+	err = tw.fw.checkFile()
+	tw.Require().NoError(err, "expected no error when checking file, got '%v'", err)
+
+	tw.requireReopened()
+}
+
+func (tw *testWatchSuite) TestTruncated() {
+	err := os.Truncate(tw.fileName, 0)
+	tw.Require().NoError(err, "expected no error when truncating file, got '%v'", err)
+
+	err = tw.fw.checkFile()
+	tw.Require().NoError(err, "expected no error when checking file, got '%v'", err)
+
+	tw.Require().Zerof(tw.fw.Size, "expected file size to be resynchronized, got '%v'", tw.fw.Size)
+}
@@ -1,7 +1,6 @@
 package filewriter
 
 import (
-	"bufio"
 	"bytes"
 	"testing"
 
@@ -12,7 +11,7 @@ func TestWriteCounter(t *testing.T) {
 	var writer bytes.Buffer
 	wc := &writeCounter{wr: &writer}
 
-	buf := bufio.NewWriter(wc)
+	buf := NewBuffer(wc, 0)
 
 	payload := []byte("Hello, world!\n")
 	payloadSize := uint(len(payload))